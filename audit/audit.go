@@ -0,0 +1,213 @@
+// Package audit keeps a log of every change train makes on github, so that
+// who changed what, and when, may be answered later.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gomicro/train/client"
+	"github.com/gomicro/train/config"
+)
+
+const (
+	logFile = "audit.jsonl"
+
+	// Stdout is the destination writing the log to stdout rather than a
+	// file, for collection by another tool
+	Stdout = "-"
+)
+
+// Path returns where the log is kept for the current user when no other
+// destination is configured.
+func Path() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, logFile), nil
+}
+
+// Log appends a record of each change to its destination.
+type Log struct {
+	path string
+	out  io.Writer
+}
+
+// New returns a log appending to the file at the path given, or writing to
+// out if the path is Stdout.
+func New(path string, out io.Writer) *Log {
+	return &Log{
+		path: path,
+		out:  out,
+	}
+}
+
+// Audit appends the record of a change, syncing it to disk before returning
+// when the log is kept in a file.
+func (l *Log) Audit(e client.AuditEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	b = append(b, '\n')
+
+	if l.path == Stdout {
+		_, err = l.out.Write(b)
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(l.path), 0700)
+	if err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+
+	torn, err := tornLine(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("read: %w", err)
+	}
+
+	// a line torn by a crash is terminated, so it does not swallow the
+	// record that follows it
+	if torn {
+		b = append([]byte{'\n'}, b...)
+	}
+
+	_, err = f.Write(b)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("write: %w", err)
+	}
+
+	err = f.Sync()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("sync: %w", err)
+	}
+
+	return f.Close()
+}
+
+// tornLine reports whether the file ends part way through a line.
+func tornLine(f *os.File) (bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	if info.Size() == 0 {
+		return false, nil
+	}
+
+	last := make([]byte, 1)
+	_, err = f.ReadAt(last, info.Size()-1)
+	if err != nil {
+		return false, err
+	}
+
+	return last[0] != '\n', nil
+}
+
+// Filter represents which records to return from a query. Empty fields match
+// every record.
+type Filter struct {
+	Repo   string
+	Actor  string
+	Action string
+	Result string
+	Since  time.Time
+}
+
+// Match reports whether the record is selected by the filter.
+func (f *Filter) Match(e *client.AuditEvent) bool {
+	if f.Repo != "" && !matchRepo(f.Repo, e.Repo) {
+		return false
+	}
+
+	if f.Actor != "" && !strings.EqualFold(f.Actor, e.Actor) {
+		return false
+	}
+
+	if f.Action != "" && !strings.EqualFold(f.Action, e.Action) {
+		return false
+	}
+
+	if f.Result != "" && !strings.EqualFold(f.Result, e.Result) {
+		return false
+	}
+
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+
+	return true
+}
+
+// Query returns the records in the log at the path given that match the
+// filter, oldest first.
+func Query(path string, f *Filter) ([]*client.AuditEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("audit: open: %w", err)
+	}
+	defer file.Close()
+
+	var events []*client.AuditEvent
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var e client.AuditEvent
+		err := json.Unmarshal(scanner.Bytes(), &e)
+		if err != nil {
+			// a line torn by a crash mid write is skipped
+			continue
+		}
+
+		if f.Match(&e) {
+			events = append(events, &e)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("audit: read: %w", err)
+	}
+
+	return events, nil
+}
+
+// matchRepo matches a repo by owner/name, or by name alone across owners.
+func matchRepo(want, repo string) bool {
+	if strings.EqualFold(want, repo) {
+		return true
+	}
+
+	if strings.Contains(want, "/") {
+		return false
+	}
+
+	_, name, _ := strings.Cut(repo, "/")
+
+	return strings.EqualFold(want, name)
+}
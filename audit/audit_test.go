@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/train/client"
+	. "github.com/onsi/gomega"
+)
+
+func TestAudit(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	records := []client.AuditEvent{
+		{Time: day, Actor: "octocat", Repo: "gomicro/steward", Action: client.ChangeCreate, Number: 3, Result: client.ResultDone},
+		{Time: day.Add(time.Hour), Actor: "hubot", Repo: "gomicro/penname", Action: client.ChangeMerge, Number: 7, Result: client.ResultFailed},
+		{Time: day.Add(2 * time.Hour), Actor: "octocat", Repo: "acme/steward", Action: client.ChangeMerge, Number: 1, Result: client.ResultDone},
+	}
+
+	g.Describe("Log", func() {
+		g.It("should append records to a file", func() {
+			path := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+			l := New(path, nil)
+
+			for _, r := range records {
+				Expect(l.Audit(r)).To(Succeed())
+			}
+
+			events, err := Query(path, &Filter{})
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(3))
+			Expect(*events[0]).To(Equal(records[0]))
+			Expect(*events[2]).To(Equal(records[2]))
+		})
+
+		g.It("should write records to the writer given for stdout", func() {
+			buf := &bytes.Buffer{}
+			l := New(Stdout, buf)
+
+			Expect(l.Audit(records[0])).To(Succeed())
+			Expect(buf.String()).To(HavePrefix(`{"time":"2024-03-01T12:00:00Z","actor":"octocat"`))
+			Expect(buf.String()).To(HaveSuffix("}\n"))
+		})
+	})
+
+	g.Describe("Query", func() {
+		var path string
+
+		g.BeforeEach(func() {
+			path = filepath.Join(t.TempDir(), "audit.jsonl")
+			l := New(path, nil)
+
+			for _, r := range records {
+				Expect(l.Audit(r)).To(Succeed())
+			}
+		})
+
+		g.It("should return nothing for a log not yet written", func() {
+			events, err := Query(filepath.Join(t.TempDir(), "missing.jsonl"), &Filter{})
+			Expect(err).To(BeNil())
+			Expect(events).To(BeEmpty())
+		})
+
+		g.It("should skip a line torn by a crash", func() {
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+			Expect(err).To(BeNil())
+			_, err = f.WriteString(`{"time":"2024-03-01T15:00:00Z","actor":"octo`)
+			Expect(err).To(BeNil())
+			Expect(f.Close()).To(Succeed())
+
+			Expect(New(path, nil).Audit(records[1])).To(Succeed())
+
+			events, err := Query(path, &Filter{})
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(4))
+			Expect(events[2].Repo).To(Equal("acme/steward"))
+			Expect(*events[3]).To(Equal(records[1]))
+		})
+
+		g.It("should filter by repo name across owners", func() {
+			events, err := Query(path, &Filter{Repo: "steward"})
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(2))
+		})
+
+		g.It("should filter by owner and repo", func() {
+			events, err := Query(path, &Filter{Repo: "GoMicro/Steward"})
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Number).To(Equal(3))
+		})
+
+		g.It("should filter by actor, action, and result", func() {
+			events, err := Query(path, &Filter{Actor: "octocat", Action: "merge"})
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Repo).To(Equal("acme/steward"))
+
+			events, err = Query(path, &Filter{Result: client.ResultFailed})
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Actor).To(Equal("hubot"))
+		})
+
+		g.It("should filter by time", func() {
+			events, err := Query(path, &Filter{Since: day.Add(time.Hour)})
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].Repo).To(Equal("gomicro/penname"))
+		})
+	})
+}
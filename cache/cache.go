@@ -0,0 +1,236 @@
+// Package cache provides an on disk store of github responses, so they may be
+// revalidated with conditional requests instead of downloaded again.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomicro/train/config"
+)
+
+const (
+	cacheDir = "cache"
+
+	// DefaultMaxSize is how large the cache may grow, in bytes, before the
+	// entries used least recently are evicted.
+	DefaultMaxSize = 64 << 20
+)
+
+// Entry represents a cached response along with the validators used to
+// revalidate it.
+type Entry struct {
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+}
+
+// Cache represents a directory of cached responses, addressed by key, kept
+// under a maximum size.
+type Cache struct {
+	dir     string
+	maxSize int64
+
+	mtx sync.Mutex
+	// size is the total size of the entries kept, or -1 until it is measured
+	size int64
+}
+
+// New returns a cache kept in the directory given, evicting the entries used
+// least recently once it grows past the maximum size given in bytes.
+func New(dir string, maxSize int64) *Cache {
+	return &Cache{
+		dir:     dir,
+		maxSize: maxSize,
+		size:    -1,
+	}
+}
+
+// Dir returns the directory the cache is kept in for the current user.
+func Dir() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, cacheDir), nil
+}
+
+// Key derives a file safe key from the parts identifying a response.
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the entry kept for the key, or nil if there is none.
+func (c *Cache) Get(key string) (*Entry, error) {
+	b, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("cache: read: %w", err)
+	}
+
+	var e Entry
+	err = json.Unmarshal(b, &e)
+	if err != nil {
+		// a corrupt entry is treated as missing, and replaced on the next put
+		return nil, nil
+	}
+
+	// the modification time records when an entry was last used, which
+	// decides what is evicted first
+	now := time.Now()
+	os.Chtimes(c.path(key), now, now) //nolint: errcheck
+
+	return &e, nil
+}
+
+// Put keeps the entry for the key, replacing any entry already kept.
+func (c *Cache) Put(key string, e *Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("cache: marshal: %w", err)
+	}
+
+	err = os.MkdirAll(c.dir, 0700)
+	if err != nil {
+		return fmt.Errorf("cache: create dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cache: write: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(b)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("cache: write: %w", err)
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("cache: write: %w", err)
+	}
+
+	var replaced int64
+	if fi, err := os.Stat(c.path(key)); err == nil {
+		replaced = fi.Size()
+	}
+
+	err = os.Rename(tmp.Name(), c.path(key))
+	if err != nil {
+		return fmt.Errorf("cache: write: %w", err)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.size >= 0 {
+		c.size += int64(len(b)) - replaced
+	}
+
+	if c.size < 0 || c.size > c.maxSize {
+		err = c.evict()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evict measures the entries kept and, if they are over the maximum size,
+// removes those used least recently until they are back under it.
+func (c *Cache) evict() error {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("cache: read dir: %w", err)
+	}
+
+	var files []os.FileInfo
+	var size int64
+	for _, de := range dirEntries {
+		if de.IsDir() || strings.HasSuffix(de.Name(), ".tmp") {
+			continue
+		}
+
+		fi, err := de.Info()
+		if err != nil {
+			continue
+		}
+
+		files = append(files, fi)
+		size += fi.Size()
+	}
+
+	if size > c.maxSize {
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].ModTime().Before(files[j].ModTime())
+		})
+
+		for _, fi := range files {
+			if size <= c.maxSize {
+				break
+			}
+
+			err := os.Remove(filepath.Join(c.dir, fi.Name()))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("cache: evict: %w", err)
+			}
+
+			size -= fi.Size()
+		}
+	}
+
+	c.size = size
+
+	return nil
+}
+
+// Clear removes every entry kept, returning how many there were.
+func (c *Cache) Clear() (int, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("cache: read dir: %w", err)
+	}
+
+	err = os.RemoveAll(c.dir)
+	if err != nil {
+		return 0, fmt.Errorf("cache: clear: %w", err)
+	}
+
+	c.mtx.Lock()
+	c.size = 0
+	c.mtx.Unlock()
+
+	return len(entries), nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestCache(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Cache", func() {
+		var dir string
+
+		g.BeforeEach(func() {
+			dir = filepath.Join(t.TempDir(), "cache")
+		})
+
+		g.It("should keep and return entries by key", func() {
+			c := New(dir, DefaultMaxSize)
+
+			e, err := c.Get(Key("a"))
+			Expect(err).To(BeNil())
+			Expect(e).To(BeNil())
+
+			Expect(c.Put(Key("a"), &Entry{ETag: `"abc"`, Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{}`)})).To(Succeed())
+
+			e, err = c.Get(Key("a"))
+			Expect(err).To(BeNil())
+			Expect(e.ETag).To(Equal(`"abc"`))
+			Expect(e.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(string(e.Body)).To(Equal(`{}`))
+		})
+
+		g.It("should treat a corrupt entry as missing", func() {
+			c := New(dir, DefaultMaxSize)
+
+			Expect(os.MkdirAll(dir, 0700)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, Key("a")), []byte(`{"etag":`), 0600)).To(Succeed())
+
+			e, err := c.Get(Key("a"))
+			Expect(err).To(BeNil())
+			Expect(e).To(BeNil())
+		})
+
+		g.It("should evict the entries used least recently once over its size", func() {
+			body := []byte(strings.Repeat("x", 1000))
+
+			c := New(dir, 5000)
+
+			old := time.Now().Add(-time.Hour)
+			for i, k := range []string{"a", "b", "c"} {
+				Expect(c.Put(Key(k), &Entry{Body: body})).To(Succeed())
+
+				at := old.Add(time.Duration(i) * time.Minute)
+				Expect(os.Chtimes(filepath.Join(dir, Key(k)), at, at)).To(Succeed())
+			}
+
+			// using a marks it as the most recently used
+			e, err := c.Get(Key("a"))
+			Expect(err).To(BeNil())
+			Expect(e).NotTo(BeNil())
+
+			Expect(c.Put(Key("d"), &Entry{Body: body})).To(Succeed())
+
+			for k, kept := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+				e, err := c.Get(Key(k))
+				Expect(err).To(BeNil())
+
+				if kept {
+					Expect(e).NotTo(BeNil(), k)
+				} else {
+					Expect(e).To(BeNil(), k)
+				}
+			}
+		})
+
+		g.It("should measure entries already on disk before evicting", func() {
+			body := []byte(strings.Repeat("x", 1000))
+
+			first := New(dir, 5000)
+			for _, k := range []string{"a", "b", "c"} {
+				Expect(first.Put(Key(k), &Entry{Body: body})).To(Succeed())
+			}
+
+			old := time.Now().Add(-time.Hour)
+			Expect(os.Chtimes(filepath.Join(dir, Key("a")), old, old)).To(Succeed())
+
+			second := New(dir, 5000)
+			Expect(second.Put(Key("d"), &Entry{Body: body})).To(Succeed())
+
+			e, err := second.Get(Key("a"))
+			Expect(err).To(BeNil())
+			Expect(e).To(BeNil())
+
+			entries, err := os.ReadDir(dir)
+			Expect(err).To(BeNil())
+			Expect(entries).To(HaveLen(3))
+		})
+
+		g.It("should clear every entry", func() {
+			c := New(dir, DefaultMaxSize)
+
+			Expect(c.Put(Key("a"), &Entry{Body: []byte(`{}`)})).To(Succeed())
+			Expect(c.Put(Key("b"), &Entry{Body: []byte(`{}`)})).To(Succeed())
+
+			n, err := c.Clear()
+			Expect(err).To(BeNil())
+			Expect(n).To(Equal(2))
+
+			e, err := c.Get(Key("a"))
+			Expect(err).To(BeNil())
+			Expect(e).To(BeNil())
+		})
+	})
+}
@@ -0,0 +1,334 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomicro/train/config"
+	"golang.org/x/oauth2"
+)
+
+const (
+	defaultAPIURL = "https://api.github.com/"
+
+	// jwtLifetime is kept under the ten minute maximum github allows, leaving
+	// room for clock drift.
+	jwtLifetime = 9 * time.Minute
+	jwtBackdate = time.Minute
+
+	// tokenRefresh is how long before an installation token expires that a
+	// new one is minted, so none expires while a request is in flight.
+	tokenRefresh = time.Minute
+)
+
+var ErrNoInstallation = errors.New("no app installation")
+
+// appAuth authenticates as a GitHub App, minting an installation token for
+// each org or user the app is installed on as requests are made against them.
+type appAuth struct {
+	id             int64
+	installationID int64
+	key            *rsa.PrivateKey
+	apiURL         string
+	httpClient     *http.Client
+
+	mtx           sync.Mutex
+	installations map[string]int64
+	tokens        map[int64]*installationToken
+
+	// now is swapped out in tests
+	now func() time.Time
+}
+
+func newAppAuth(app *config.GithubApp, apiURL string, httpClient *http.Client) (*appAuth, error) {
+	b, err := os.ReadFile(app.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("read private key: %w", err)
+	}
+
+	key, err := parsePrivateKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+
+	return &appAuth{
+		id:             app.ID,
+		installationID: app.InstallationID,
+		key:            key,
+		apiURL:         apiURL,
+		httpClient:     httpClient,
+
+		installations: map[string]int64{},
+		tokens:        map[int64]*installationToken{},
+
+		now: time.Now,
+	}, nil
+}
+
+// RoundTrip authorizes a request with a token for the installation owning the
+// resource requested.
+func (a *appAuth) RoundTrip(req *http.Request) (*http.Response, error) {
+	it, err := a.installationToken(req.Context(), ownerOf(req.URL.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	tkn, err := it.token(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("installation token: %w", err)
+	}
+
+	r := req.Clone(req.Context())
+	tkn.SetAuthHeader(r)
+
+	return a.httpClient.Transport.RoundTrip(r)
+}
+
+// installationToken returns the token for the installation on the owner
+// given.
+func (a *appAuth) installationToken(ctx context.Context, owner string) (*installationToken, error) {
+	id, err := a.installation(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	it, ok := a.tokens[id]
+	if !ok {
+		it = &installationToken{app: a, id: id}
+		a.tokens[id] = it
+	}
+
+	return it, nil
+}
+
+// installation returns the id of the app's installation on the owner given,
+// falling back to the configured installation for requests not scoped to an
+// owner.
+func (a *appAuth) installation(ctx context.Context, owner string) (int64, error) {
+	if owner == "" {
+		if a.installationID == 0 {
+			return 0, fmt.Errorf("%w: request is not scoped to an org or user, set an installation id", ErrNoInstallation)
+		}
+
+		return a.installationID, nil
+	}
+
+	key := strings.ToLower(owner)
+
+	a.mtx.Lock()
+	id, ok := a.installations[key]
+	a.mtx.Unlock()
+
+	if ok {
+		return id, nil
+	}
+
+	var inst struct {
+		ID int64 `json:"id"`
+	}
+
+	found := false
+	for _, p := range []string{"orgs/%s/installation", "users/%s/installation"} {
+		status, err := a.appRequest(ctx, http.MethodGet, fmt.Sprintf(p, owner), &inst)
+		if err != nil {
+			return 0, err
+		}
+
+		if status == http.StatusOK {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		if a.installationID == 0 {
+			return 0, fmt.Errorf("%w: %s", ErrNoInstallation, owner)
+		}
+
+		inst.ID = a.installationID
+	}
+
+	a.mtx.Lock()
+	a.installations[key] = inst.ID
+	a.mtx.Unlock()
+
+	return inst.ID, nil
+}
+
+// appRequest makes a request authenticated as the app itself, decoding any
+// successful response into v. It returns the status of the response.
+func (a *appAuth) appRequest(ctx context.Context, method, path string, v interface{}) (int, error) {
+	jwt, err := a.jwt()
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.apiURL+path, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("app request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return resp.StatusCode, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("app request: %s %s: %s", method, path, resp.Status)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("app request: decode: %w", err)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// jwt returns a token signed by the app's private key, identifying the app.
+func (a *appAuth) jwt() (string, error) {
+	now := a.now()
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-jwtBackdate).Unix(),
+		"exp": now.Add(jwtLifetime).Unix(),
+		"iss": a.id,
+	})
+	if err != nil {
+		return "", fmt.Errorf("jwt: %w", err)
+	}
+
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	sum := sha256.Sum256([]byte(unsigned))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("jwt: sign: %w", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// installationToken holds the current token for a single installation.
+type installationToken struct {
+	app *appAuth
+	id  int64
+
+	mtx sync.Mutex
+	tkn *oauth2.Token
+}
+
+// token returns the current token for the installation, minting a new one
+// with the context given if there is none yet or it is about to expire.
+func (it *installationToken) token(ctx context.Context) (*oauth2.Token, error) {
+	it.mtx.Lock()
+	defer it.mtx.Unlock()
+
+	if it.tkn != nil && it.app.now().Add(tokenRefresh).Before(it.tkn.Expiry) {
+		return it.tkn, nil
+	}
+
+	var tkn struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	_, err := it.app.appRequest(ctx, http.MethodPost, fmt.Sprintf("app/installations/%d/access_tokens", it.id), &tkn)
+	if err != nil {
+		return nil, err
+	}
+
+	if tkn.Token == "" {
+		return nil, fmt.Errorf("%w: %d", ErrNoInstallation, it.id)
+	}
+
+	it.tkn = &oauth2.Token{
+		AccessToken: tkn.Token,
+		TokenType:   "token",
+		Expiry:      tkn.ExpiresAt,
+	}
+
+	return it.tkn, nil
+}
+
+func parsePrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(bytes.TrimSpace(b))
+	if block == nil {
+		return nil, fmt.Errorf("no pem block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not rsa")
+	}
+
+	return key, nil
+}
+
+// ownerOf returns the org or user a request is scoped to, derived from its
+// path, or an empty string if it is not scoped to one.
+func ownerOf(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	// enterprise hosts serve the api under a prefix
+	if len(parts) > 1 && parts[0] == "api" && parts[1] == "v3" {
+		parts = parts[2:]
+	}
+
+	if len(parts) < 2 {
+		return ""
+	}
+
+	switch parts[0] {
+	case "repos", "orgs", "users":
+		return parts[1]
+	default:
+		return ""
+	}
+}
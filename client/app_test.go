@@ -0,0 +1,260 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/train/config"
+	. "github.com/onsi/gomega"
+)
+
+func TestAppAuth(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %s", err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "app.pem")
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
+	if err != nil {
+		t.Fatalf("write key: %s", err)
+	}
+
+	g.Describe("App Auth", func() {
+		var mtx sync.Mutex
+		var minted map[string]int
+		var lookups []string
+		var badJWT []string
+		var now time.Time
+		var srv *httptest.Server
+
+		// the fake host lists the app as installed on the gomicro org as 11,
+		// and on the octocat user as 22
+		g.BeforeEach(func() {
+			minted = map[string]int{}
+			lookups = nil
+			badJWT = nil
+			now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+			mux := http.NewServeMux()
+			app := func(h http.HandlerFunc) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					mtx.Lock()
+					if !validJWT(&key.PublicKey, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), 42) {
+						badJWT = append(badJWT, r.URL.Path)
+					}
+					mtx.Unlock()
+
+					h(w, r)
+				}
+			}
+
+			mux.HandleFunc("/orgs/gomicro/installation", app(func(w http.ResponseWriter, r *http.Request) {
+				mtx.Lock()
+				lookups = append(lookups, r.URL.Path)
+				mtx.Unlock()
+
+				fmt.Fprint(w, `{"id":11}`)
+			}))
+			mux.HandleFunc("/users/octocat/installation", app(func(w http.ResponseWriter, r *http.Request) {
+				mtx.Lock()
+				lookups = append(lookups, r.URL.Path)
+				mtx.Unlock()
+
+				fmt.Fprint(w, `{"id":22}`)
+			}))
+			mux.HandleFunc("/orgs/", app(func(w http.ResponseWriter, r *http.Request) {
+				mtx.Lock()
+				lookups = append(lookups, r.URL.Path)
+				mtx.Unlock()
+
+				http.NotFound(w, r)
+			}))
+			mux.HandleFunc("/users/", app(func(w http.ResponseWriter, r *http.Request) {
+				mtx.Lock()
+				lookups = append(lookups, r.URL.Path)
+				mtx.Unlock()
+
+				http.NotFound(w, r)
+			}))
+			mux.HandleFunc("/app/installations/", app(func(w http.ResponseWriter, r *http.Request) {
+				id := strings.Split(strings.TrimPrefix(r.URL.Path, "/app/installations/"), "/")[0]
+
+				mtx.Lock()
+				minted[id]++
+				n := minted[id]
+				mtx.Unlock()
+
+				fmt.Fprintf(w, `{"token":"tkn-%s-%d","expires_at":%q}`, id, n, now.Add(time.Hour).Format(time.RFC3339))
+			}))
+			mux.HandleFunc("/repos/", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, r.Header.Get("Authorization"))
+			})
+			mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, r.Header.Get("Authorization"))
+			})
+
+			srv = httptest.NewServer(mux)
+		})
+
+		g.AfterEach(func() {
+			srv.Close()
+		})
+
+		newAuth := func(installationID int64) *appAuth {
+			a, err := newAppAuth(&config.GithubApp{ID: 42, InstallationID: installationID, PrivateKeyFile: keyFile}, srv.URL, &http.Client{Transport: http.DefaultTransport})
+			Expect(err).To(BeNil())
+
+			a.now = func() time.Time { return now }
+
+			return a
+		}
+
+		get := func(a *appAuth, ctx context.Context, path string) (string, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+			Expect(err).To(BeNil())
+
+			resp, err := a.RoundTrip(req)
+			if err != nil {
+				return "", err
+			}
+			defer resp.Body.Close()
+
+			b, err := io.ReadAll(resp.Body)
+			return string(b), err
+		}
+
+		g.It("should authorize requests with the token of the owner's installation", func() {
+			a := newAuth(0)
+
+			auth, err := get(a, context.Background(), "/repos/gomicro/train/pulls")
+			Expect(err).To(BeNil())
+			Expect(auth).To(Equal("token tkn-11-1"))
+
+			auth, err = get(a, context.Background(), "/repos/octocat/hello/pulls")
+			Expect(err).To(BeNil())
+			Expect(auth).To(Equal("token tkn-22-1"))
+
+			auth, err = get(a, context.Background(), "/repos/GoMicro/steward/pulls")
+			Expect(err).To(BeNil())
+			Expect(auth).To(Equal("token tkn-11-1"))
+
+			Expect(lookups).To(Equal([]string{
+				"/orgs/gomicro/installation",
+				"/orgs/octocat/installation",
+				"/users/octocat/installation",
+			}))
+			Expect(minted).To(Equal(map[string]int{"11": 1, "22": 1}))
+			Expect(badJWT).To(BeEmpty())
+		})
+
+		g.It("should mint a new token shortly before the current one expires", func() {
+			a := newAuth(0)
+
+			auth, err := get(a, context.Background(), "/repos/gomicro/train/pulls")
+			Expect(err).To(BeNil())
+			Expect(auth).To(Equal("token tkn-11-1"))
+
+			now = now.Add(58 * time.Minute)
+
+			auth, err = get(a, context.Background(), "/repos/gomicro/train/pulls")
+			Expect(err).To(BeNil())
+			Expect(auth).To(Equal("token tkn-11-1"))
+
+			now = now.Add(90 * time.Second)
+
+			auth, err = get(a, context.Background(), "/repos/gomicro/train/pulls")
+			Expect(err).To(BeNil())
+			Expect(auth).To(Equal("token tkn-11-2"))
+
+			Expect(minted["11"]).To(Equal(2))
+			Expect(badJWT).To(BeEmpty())
+		})
+
+		g.It("should fall back to the configured installation", func() {
+			a := newAuth(33)
+
+			auth, err := get(a, context.Background(), "/search/repositories")
+			Expect(err).To(BeNil())
+			Expect(auth).To(Equal("token tkn-33-1"))
+
+			auth, err = get(a, context.Background(), "/repos/elsewhere/repo/pulls")
+			Expect(err).To(BeNil())
+			Expect(auth).To(Equal("token tkn-33-1"))
+
+			Expect(minted).To(Equal(map[string]int{"33": 1}))
+		})
+
+		g.It("should return an error when there is no installation to use", func() {
+			a := newAuth(0)
+
+			_, err := get(a, context.Background(), "/search/repositories")
+			Expect(err).To(MatchError(ErrNoInstallation))
+
+			_, err = get(a, context.Background(), "/repos/elsewhere/repo/pulls")
+			Expect(err).To(MatchError(ErrNoInstallation))
+		})
+
+		g.It("should mint tokens with the context of the request", func() {
+			a := newAuth(33)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := get(a, ctx, "/search/repositories")
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(minted).To(BeEmpty())
+		})
+	})
+}
+
+// validJWT reports whether the token is signed by the key given and issued by
+// the app given.
+func validJWT(pub *rsa.PublicKey, jwt string, app int64) bool {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return false
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig) != nil {
+		return false
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+
+	var claims struct {
+		Iss int64 `json:"iss"`
+	}
+
+	return json.Unmarshal(b, &claims) == nil && claims.Iss == app
+}
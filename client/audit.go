@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AuditEvent represents a single change made on github. BeforeSHA and
+// AfterSHA are the commits the base branch of the PR was at before and after
+// the change, and HeadSHA the commit being released.
+type AuditEvent struct {
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor"`
+	Host      string    `json:"host"`
+	Repo      string    `json:"repo"`
+	Action    string    `json:"action"`
+	Number    int       `json:"number,omitempty"`
+	BeforeSHA string    `json:"before_sha,omitempty"`
+	AfterSHA  string    `json:"after_sha,omitempty"`
+	HeadSHA   string    `json:"head_sha,omitempty"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Auditor receives an event for each change made on github, as it is made.
+type Auditor interface {
+	Audit(AuditEvent) error
+}
+
+// SetAuditor sets where an event for each change made on github is sent.
+func (c *Client) SetAuditor(a Auditor) {
+	c.auditor = a
+}
+
+// audit sends the event for a change, completed with who made it and where,
+// and the error it failed with if any.
+func (c *Client) audit(ctx context.Context, e AuditEvent, changeErr error) error {
+	if c.auditor == nil {
+		return nil
+	}
+
+	e.Time = time.Now().UTC()
+	e.Actor = c.actor(ctx)
+	e.Host = c.ghClient.BaseURL.Hostname()
+	e.Result = ResultDone
+
+	if changeErr != nil {
+		e.Result = ResultFailed
+		e.Error = changeErr.Error()
+	}
+
+	err := c.auditor.Audit(e)
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	return nil
+}
+
+// actor returns the login changes are being made as, fetching it the first
+// time it is needed.
+func (c *Client) actor(ctx context.Context) string {
+	if c.login != "" {
+		return c.login
+	}
+
+	if c.cfg.Github.App.IsSet() {
+		c.login = fmt.Sprintf("app %d", c.cfg.Github.App.ID)
+		return c.login
+	}
+
+	c.rate.Wait(ctx) //nolint: errcheck
+	user, _, err := c.ghClient.Users.Get(ctx, "")
+	if err != nil {
+		return "unknown"
+	}
+
+	c.login = user.GetLogin()
+
+	return c.login
+}
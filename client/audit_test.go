@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+// events collects the events audited by a client.
+type events []AuditEvent
+
+func (e *events) Audit(ev AuditEvent) error {
+	*e = append(*e, ev)
+	return nil
+}
+
+const releasePR = `{"number":3,"state":"open","html_url":"https://github.com/gomicro/steward/pull/3","mergeable_state":"clean",` +
+	`"head":{"ref":"master","sha":"head1"},` +
+	`"base":{"ref":"release","sha":"base1","repo":{"name":"steward","owner":{"login":"gomicro"}}}}`
+
+func TestAudit(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	repos := []*github.Repository{{
+		Name:          github.String("steward"),
+		Owner:         &github.User{Login: github.String("gomicro")},
+		DefaultBranch: github.String("master"),
+	}}
+
+	g.Describe("Auditing changes", func() {
+		var (
+			mux  *http.ServeMux
+			ev   *events
+			open bool
+		)
+
+		g.BeforeEach(func() {
+			ev = &events{}
+			open = false
+
+			mux = http.NewServeMux()
+			mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"login":"octocat"}`)
+			})
+			serveBranch(mux, "master", "head1")
+			serveBranch(mux, "release", "base1")
+			mux.HandleFunc("/repos/gomicro/steward/compare/release...master", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"commits":[{"commit":{"message":"added auditing"}}]}`)
+			})
+			mux.HandleFunc("/repos/gomicro/steward/pulls", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprint(w, releasePR)
+					return
+				}
+
+				if open {
+					fmt.Fprintf(w, "[%s]", releasePR)
+					return
+				}
+
+				fmt.Fprint(w, `[]`)
+			})
+			mux.HandleFunc("/repos/gomicro/steward/pulls/3", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, releasePR)
+			})
+			mux.HandleFunc("/repos/gomicro/steward/pulls/3/merge", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"merged":true,"sha":"merged1"}`)
+			})
+			mux.HandleFunc("/", http.NotFound)
+		})
+
+		g.It("should audit creating a release PR", func() {
+			c := newTestClient(t, mux)
+			c.SetAuditor(ev)
+
+			_, err := c.ProcessRepos(context.Background(), testProgress(), repos, false)
+			Expect(err).To(BeNil())
+			Expect(*ev).To(HaveLen(1))
+
+			e := (*ev)[0]
+			Expect(e.Action).To(Equal(ChangeCreate))
+			Expect(e.Actor).To(Equal("octocat"))
+			Expect(e.Host).To(Equal("127.0.0.1"))
+			Expect(e.Repo).To(Equal("gomicro/steward"))
+			Expect(e.Number).To(Equal(3))
+			Expect(e.BeforeSHA).To(Equal("base1"))
+			Expect(e.AfterSHA).To(Equal("base1"))
+			Expect(e.HeadSHA).To(Equal("head1"))
+			Expect(e.Result).To(Equal(ResultDone))
+		})
+
+		g.It("should audit editing a release PR", func() {
+			open = true
+
+			c := newTestClient(t, mux)
+			c.SetAuditor(ev)
+
+			_, err := c.ProcessRepos(context.Background(), testProgress(), repos, false)
+			Expect(err).To(BeNil())
+			Expect(*ev).To(HaveLen(1))
+
+			e := (*ev)[0]
+			Expect(e.Action).To(Equal(ChangeEdit))
+			Expect(e.Actor).To(Equal("octocat"))
+			Expect(e.Number).To(Equal(3))
+			Expect(e.HeadSHA).To(Equal("head1"))
+			Expect(e.Result).To(Equal(ResultDone))
+		})
+
+		g.It("should audit and return a failed edit", func() {
+			open = true
+
+			failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPatch {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					fmt.Fprint(w, `{"message":"Validation Failed"}`)
+					return
+				}
+
+				mux.ServeHTTP(w, r)
+			})
+
+			c := newTestClient(t, failing)
+			c.SetAuditor(ev)
+
+			_, err := c.ProcessRepos(context.Background(), testProgress(), repos, false)
+			Expect(err).To(MatchError(ContainSubstring("edit pr")))
+			Expect(*ev).To(HaveLen(1))
+			Expect((*ev)[0].Action).To(Equal(ChangeEdit))
+			Expect((*ev)[0].Result).To(Equal(ResultFailed))
+			Expect((*ev)[0].Error).To(ContainSubstring("Validation Failed"))
+		})
+
+		g.It("should audit merging a release PR", func() {
+			open = true
+
+			c := newTestClient(t, mux)
+			c.SetAuditor(ev)
+
+			urls, err := c.ReleaseRepos(context.Background(), testProgress(), repos, false)
+			Expect(err).To(BeNil())
+			Expect(urls).To(Equal([]string{"https://github.com/gomicro/steward/pull/3"}))
+			Expect(*ev).To(HaveLen(1))
+
+			e := (*ev)[0]
+			Expect(e.Action).To(Equal(ChangeMerge))
+			Expect(e.Actor).To(Equal("octocat"))
+			Expect(e.Host).To(Equal("127.0.0.1"))
+			Expect(e.BeforeSHA).To(Equal("base1"))
+			Expect(e.AfterSHA).To(Equal("merged1"))
+			Expect(e.HeadSHA).To(Equal("head1"))
+			Expect(e.Result).To(Equal(ResultDone))
+		})
+
+		g.It("should audit the changes applied from a plan", func() {
+			c := newTestClient(t, mux)
+			c.SetAuditor(ev)
+
+			_, err := c.Apply(context.Background(), testProgress(), []*Change{
+				{
+					Action:  ChangeCreate,
+					Repo:    "gomicro/steward",
+					Head:    "master",
+					Base:    "release",
+					HeadSHA: "head1",
+					BaseSHA: "base1",
+				},
+				{
+					Action:      ChangeMerge,
+					Repo:        "gomicro/steward",
+					Number:      3,
+					Head:        "master",
+					Base:        "release",
+					HeadSHA:     "head1",
+					BaseSHA:     "base1",
+					MergeMethod: "merge",
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(*ev).To(HaveLen(2))
+
+			Expect((*ev)[0].Action).To(Equal(ChangeCreate))
+			Expect((*ev)[0].Actor).To(Equal("octocat"))
+			Expect((*ev)[0].BeforeSHA).To(Equal("base1"))
+			Expect((*ev)[0].HeadSHA).To(Equal("head1"))
+
+			Expect((*ev)[1].Action).To(Equal(ChangeMerge))
+			Expect((*ev)[1].Host).To(Equal("127.0.0.1"))
+			Expect((*ev)[1].BeforeSHA).To(Equal("base1"))
+			Expect((*ev)[1].AfterSHA).To(Equal("merged1"))
+		})
+	})
+}
@@ -0,0 +1,230 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	headerOAuthScopes     = "X-OAuth-Scopes"
+	headerTokenExpiration = "GitHub-Authentication-Token-Expiration"
+	headerSSO             = "X-GitHub-SSO"
+)
+
+var (
+	ErrNoToken       = errors.New("not logged in, run `train auth` to log in")
+	ErrMissingScopes = errors.New("token is missing required scopes")
+	ErrBadToken      = errors.New("token is invalid or revoked, run `train auth` to log in again")
+
+	// requiredScopes are the token scopes train needs to open and merge
+	// release PRs.
+	requiredScopes = []string{"repo"}
+)
+
+// AuthStatus represents what github reports about the credentials train is
+// using.
+type AuthStatus struct {
+	Login string
+	// Scopes is nil when github does not report scopes, as with app and fine
+	// grained tokens
+	Scopes []string
+	Expiry string
+	Orgs   []OrgAuthStatus
+}
+
+// OrgAuthStatus represents whether the credentials may access an org's
+// resources, which requires SAML SSO authorization for some orgs.
+type OrgAuthStatus struct {
+	Org        string
+	Authorized bool
+	SSOURL     string
+}
+
+// GetAuthStatus returns the login, scopes, and expiry of the credentials in
+// use, along with their SSO authorization state for each org of the user.
+func (c *Client) GetAuthStatus(ctx context.Context) (*AuthStatus, error) {
+	if !c.hasCredentials() {
+		return nil, ErrNoToken
+	}
+
+	if c.cfg.Github.App.IsSet() {
+		return &AuthStatus{Login: fmt.Sprintf("app %d", c.cfg.Github.App.ID)}, nil
+	}
+
+	c.rate.Wait(ctx) //nolint: errcheck
+	user, resp, err := c.ghClient.Users.Get(ctx, "")
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return nil, ErrBadToken
+		}
+
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+
+	status := &AuthStatus{
+		Login:  user.GetLogin(),
+		Scopes: parseScopes(resp.Header),
+		Expiry: resp.Header.Get(headerTokenExpiration),
+	}
+
+	opts := &github.ListOptions{
+		Page:    0,
+		PerPage: 100,
+	}
+
+	var orgs []*github.Organization
+	for {
+		c.rate.Wait(ctx) //nolint: errcheck
+		page, resp, err := c.ghClient.Organizations.List(ctx, "", opts)
+		if err != nil {
+			return nil, fmt.Errorf("list orgs: %w", err)
+		}
+
+		orgs = append(orgs, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	for i := range orgs {
+		org := orgs[i].GetLogin()
+
+		c.rate.Wait(ctx) //nolint: errcheck
+		_, resp, err := c.ghClient.Repositories.ListByOrg(ctx, org, &github.RepositoryListByOrgOptions{
+			ListOptions: github.ListOptions{PerPage: 1},
+		})
+
+		orgStatus := OrgAuthStatus{Org: org, Authorized: true}
+		if err != nil && resp != nil && resp.StatusCode == http.StatusForbidden {
+			sso := resp.Header.Get(headerSSO)
+			if strings.HasPrefix(sso, "required") {
+				orgStatus.Authorized = false
+				if _, u, found := strings.Cut(sso, "url="); found {
+					orgStatus.SSOURL = u
+				}
+			}
+		}
+
+		status.Orgs = append(status.Orgs, orgStatus)
+	}
+
+	return status, nil
+}
+
+// CheckAuth confirms credentials are present and, where github reports them,
+// that the token holds the scopes train needs. It is meant to be called before
+// making any changes.
+func (c *Client) CheckAuth(ctx context.Context) error {
+	if !c.hasCredentials() {
+		return ErrNoToken
+	}
+
+	if c.cfg.Github.App.IsSet() {
+		return nil
+	}
+
+	c.rate.Wait(ctx) //nolint: errcheck
+	user, resp, err := c.ghClient.Users.Get(ctx, "")
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return ErrBadToken
+		}
+
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	c.login = user.GetLogin()
+
+	scopes := parseScopes(resp.Header)
+	if scopes == nil {
+		return nil
+	}
+
+	var missing []string
+	for _, r := range requiredScopes {
+		if !contains(scopes, r) {
+			missing = append(missing, r)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s, run `train auth --force` to grant them", ErrMissingScopes, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// RevokeToken revokes the token in use with github, using the credentials of
+// the OAuth app that issued it.
+func (c *Client) RevokeToken(ctx context.Context, clientID, clientSecret string) error {
+	body, err := json.Marshal(map[string]string{"access_token": c.cfg.Github.Token})
+	if err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+
+	u := fmt.Sprintf("%sapplications/%s/token", c.ghClient.BaseURL.String(), clientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+
+	req.SetBasicAuth(clientID, clientSecret)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return fmt.Errorf("revoke token: %s", resp.Status)
+	}
+}
+
+func (c *Client) hasCredentials() bool {
+	return c.cfg.Github.Token != "" || c.cfg.Github.App.IsSet()
+}
+
+// parseScopes returns the scopes reported for a token, or nil if none were
+// reported at all.
+func parseScopes(h http.Header) []string {
+	if _, ok := h[http.CanonicalHeaderKey(headerOAuthScopes)]; !ok {
+		return nil
+	}
+
+	scopes := []string{}
+	for _, s := range strings.Split(h.Get(headerOAuthScopes), ",") {
+		s = strings.TrimSpace(s)
+		if s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+
+	return scopes
+}
+
+func contains(list []string, value string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, value) {
+			return true
+		}
+	}
+
+	return false
+}
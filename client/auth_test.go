@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestAuthStatus(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("GetAuthStatus", func() {
+		g.It("should report on every org of the user", func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-OAuth-Scopes", "repo, read:org")
+				fmt.Fprint(w, `{"login":"octocat"}`)
+			})
+			mux.HandleFunc("/user/orgs", func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("page") == "2" {
+					fmt.Fprint(w, `[{"login":"acme"}]`)
+					return
+				}
+
+				w.Header().Set("Link", fmt.Sprintf(`<%s?page=2>; rel="next"`, r.URL.Path))
+				fmt.Fprint(w, `[{"login":"gomicro"}]`)
+			})
+			mux.HandleFunc("/orgs/gomicro/repos", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[]`)
+			})
+			mux.HandleFunc("/orgs/acme/repos", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-GitHub-SSO", "required; url=https://github.com/orgs/acme/sso")
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"message":"Resource protected by organization SAML enforcement."}`)
+			})
+
+			c := newTestClient(t, mux)
+
+			status, err := c.GetAuthStatus(context.Background())
+			Expect(err).To(BeNil())
+			Expect(status.Login).To(Equal("octocat"))
+			Expect(status.Orgs).To(Equal([]OrgAuthStatus{
+				{Org: "gomicro", Authorized: true},
+				{Org: "acme", SSOURL: "https://github.com/orgs/acme/sso"},
+			}))
+		})
+	})
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gomicro/train/cache"
+)
+
+const (
+	headerETag            = "ETag"
+	headerLastModified    = "Last-Modified"
+	headerIfNoneMatch     = "If-None-Match"
+	headerIfModifiedSince = "If-Modified-Since"
+)
+
+// cacheTransport revalidates GET requests against responses kept on disk.
+// Github does not count a 304 Not Modified response against the rate limit,
+// so unchanged resources cost nothing to fetch again.
+type cacheTransport struct {
+	base  http.RoundTripper
+	cache *cache.Cache
+	// identity distinguishes the credentials in use, as what is visible
+	// differs by who is asking
+	identity string
+}
+
+func newCacheTransport(base http.RoundTripper, c *cache.Cache, identity string) *cacheTransport {
+	return &cacheTransport{
+		base:     base,
+		cache:    c,
+		identity: identity,
+	}
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the rate limit is only useful fresh
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" || strings.HasSuffix(req.URL.Path, "/rate_limit") {
+		return t.base.RoundTrip(req)
+	}
+
+	key := cache.Key(t.identity, req.URL.String(), req.Header.Get("Accept"))
+
+	entry, err := t.cache.Get(key)
+	if err != nil {
+		entry = nil
+	}
+
+	if entry != nil {
+		req = req.Clone(req.Context())
+
+		if entry.ETag != "" {
+			req.Header.Set(headerIfNoneMatch, entry.ETag)
+		}
+
+		if entry.LastModified != "" {
+			req.Header.Set(headerIfModifiedSince, entry.LastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		return cachedResponse(req, resp, entry), nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	etag := resp.Header.Get(headerETag)
+	lastModified := resp.Header.Get(headerLastModified)
+	if etag == "" && lastModified == "" {
+		return resp, nil
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+
+	// failing to cache only costs a full download next time
+	t.cache.Put(key, &cache.Entry{ //nolint: errcheck
+		ETag:         etag,
+		LastModified: lastModified,
+		Header:       resp.Header.Clone(),
+		Body:         b,
+	})
+
+	return resp, nil
+}
+
+// cachedResponse builds a full response from a cached entry, taking the
+// current rate limit and other headers from the 304 response that confirmed
+// it.
+func cachedResponse(req *http.Request, notModified *http.Response, e *cache.Entry) *http.Response {
+	h := e.Header.Clone()
+	for k, v := range notModified.Header {
+		h[k] = v
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/train/cache"
+	. "github.com/onsi/gomega"
+)
+
+func TestCacheTransport(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Cache Transport", func() {
+		var cch *cache.Cache
+		var sent []http.Header
+
+		newTransport := func(responses ...*http.Response) *cacheTransport {
+			calls := 0
+			base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				sent = append(sent, req.Header.Clone())
+
+				resp := responses[calls]
+				calls++
+
+				return resp, nil
+			})
+
+			return newCacheTransport(base, cch, "token:abc")
+		}
+
+		get := func(rt http.RoundTripper, path string) (*http.Response, string) {
+			req, err := http.NewRequest(http.MethodGet, "https://api.github.com"+path, nil)
+			Expect(err).To(BeNil())
+
+			resp, err := rt.RoundTrip(req)
+			Expect(err).To(BeNil())
+			defer resp.Body.Close()
+
+			b, err := io.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+
+			return resp, string(b)
+		}
+
+		g.BeforeEach(func() {
+			cch = cache.New(filepath.Join(t.TempDir(), "cache"), cache.DefaultMaxSize)
+			sent = nil
+		})
+
+		g.It("should serve the cached body when github reports it unchanged", func() {
+			rt := newTransport(
+				respond(http.StatusOK, map[string]string{headerETag: `"v1"`, "Content-Type": "application/json", headerRateRemaining: "100"}, `{"name":"train"}`),
+				respond(http.StatusNotModified, map[string]string{headerETag: `"v1"`, headerRateRemaining: "99"}, ``),
+			)
+
+			resp, body := get(rt, "/repos/gomicro/train")
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(body).To(Equal(`{"name":"train"}`))
+			Expect(sent[0].Get(headerIfNoneMatch)).To(BeEmpty())
+
+			resp, body = get(rt, "/repos/gomicro/train")
+			Expect(sent[1].Get(headerIfNoneMatch)).To(Equal(`"v1"`))
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(body).To(Equal(`{"name":"train"}`))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(resp.Header.Get(headerRateRemaining)).To(Equal("99"))
+		})
+
+		g.It("should replace the cached body when it has changed", func() {
+			rt := newTransport(
+				respond(http.StatusOK, map[string]string{headerETag: `"v1"`}, `{"name":"train"}`),
+				respond(http.StatusOK, map[string]string{headerETag: `"v2"`}, `{"name":"train","archived":true}`),
+				respond(http.StatusNotModified, nil, ``),
+			)
+
+			get(rt, "/repos/gomicro/train")
+
+			_, body := get(rt, "/repos/gomicro/train")
+			Expect(body).To(Equal(`{"name":"train","archived":true}`))
+
+			_, body = get(rt, "/repos/gomicro/train")
+			Expect(sent[2].Get(headerIfNoneMatch)).To(Equal(`"v2"`))
+			Expect(body).To(Equal(`{"name":"train","archived":true}`))
+		})
+
+		g.It("should revalidate by last modified time without an etag", func() {
+			lastModified := "Mon, 19 Oct 2026 12:00:00 GMT"
+
+			rt := newTransport(
+				respond(http.StatusOK, map[string]string{headerLastModified: lastModified}, `[]`),
+				respond(http.StatusNotModified, nil, ``),
+			)
+
+			get(rt, "/orgs/gomicro/repos")
+
+			_, body := get(rt, "/orgs/gomicro/repos")
+			Expect(sent[1].Get(headerIfModifiedSince)).To(Equal(lastModified))
+			Expect(body).To(Equal(`[]`))
+		})
+
+		g.It("should never cache the rate limit", func() {
+			rt := newTransport(
+				respond(http.StatusOK, map[string]string{headerETag: `"v1"`}, `{}`),
+				respond(http.StatusOK, map[string]string{headerETag: `"v1"`}, `{}`),
+			)
+
+			get(rt, "/rate_limit")
+			get(rt, "/rate_limit")
+			Expect(sent[1].Get(headerIfNoneMatch)).To(BeEmpty())
+		})
+	})
+}
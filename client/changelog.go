@@ -15,7 +15,9 @@ var (
 Release PR created with ` + "`train`"
 )
 
-func (c *Client) createChangeLog(ctx context.Context, owner, name, base, head string) (map[string][]string, error) {
+// createChangeLog returns the changes found in the messages of the commits
+// the head is ahead of the base by, along with how many commits there are.
+func (c *Client) createChangeLog(ctx context.Context, owner, name, base, head string) (map[string][]string, int, error) {
 	changes := map[string][]string{
 		"added":      {},
 		"changed":    {},
@@ -27,11 +29,11 @@ func (c *Client) createChangeLog(ctx context.Context, owner, name, base, head st
 
 	comp, _, err := c.ghClient.Repositories.CompareCommits(ctx, owner, name, base, head)
 	if err != nil {
-		return nil, fmt.Errorf("compare commits: %w", err)
+		return nil, 0, fmt.Errorf("compare commits: %w", err)
 	}
 
 	if len(comp.Commits) == 0 {
-		return nil, ErrNoCommits
+		return nil, 0, ErrNoCommits
 	}
 
 	for _, commit := range comp.Commits {
@@ -54,7 +56,20 @@ func (c *Client) createChangeLog(ctx context.Context, owner, name, base, head st
 		}
 	}
 
-	return changes, nil
+	return changes, len(comp.Commits), nil
+}
+
+// summarize returns how many of each kind of change there are, such as
+// "2 added, 1 fixed".
+func summarize(changes map[string][]string) string {
+	var counts []string
+	for _, label := range changeOrder {
+		if n := len(changes[label]); n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, label))
+		}
+	}
+
+	return strings.Join(counts, ", ")
 }
 
 func prBody(prBodyTemplate string, changes map[string][]string) string {
@@ -4,9 +4,12 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"strings"
 
+	"github.com/gomicro/train/cache"
 	"github.com/gomicro/train/config"
 	"github.com/gomicro/trust"
 	"github.com/google/go-github/github"
@@ -15,15 +18,29 @@ import (
 )
 
 type Client struct {
-	cfg      *config.Config
-	ghClient *github.Client
-	rate     *rate.Limiter
+	cfg        *config.Config
+	ghClient   *github.Client
+	httpClient *http.Client
+	rate       *rate.Limiter
+	verbose    io.Writer
 
 	ignoreRepoMap  map[string]struct{}
 	ignoreTopicMap map[string]struct{}
+
+	orgSettings  map[string]*orgSettings
+	repoSettings map[string]*repoSettings
+	repoStates   map[string]*repoState
+
+	recorder Recorder
+	auditor  Auditor
+	// login is who changes are being made as, once known
+	login string
 }
 
-func New(cfg *config.Config) (*Client, error) {
+// New returns a client for the github host configured. Details useful when
+// troubleshooting, such as retried requests, are written to verbose. Responses
+// are revalidated against the cache given, unless it is nil.
+func New(cfg *config.Config, verbose io.Writer, cch *cache.Cache) (*Client, error) {
 	pool := trust.New()
 
 	certs, err := pool.CACerts()
@@ -37,37 +54,75 @@ func New(cfg *config.Config) (*Client, error) {
 		},
 	}
 
-	ctx := context.Background()
-	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
-
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{
-			AccessToken: cfg.Github.Token,
-		},
-	)
-
 	rl := rate.NewLimiter(
 		rate.Limit(cfg.Github.Limits.RequestsPerSecond),
 		cfg.Github.Limits.Burst,
 	)
 
-	irMap := map[string]struct{}{}
-	for i := range cfg.Github.Ignores.Repos {
-		irMap[strings.ToLower(cfg.Github.Ignores.Repos[i])] = struct{}{}
+	var authClient *http.Client
+	var identity string
+	if cfg.Github.App.IsSet() {
+		app, err := newAppAuth(cfg.Github.App, cfg.Github.APIURL, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up app auth: %v", err.Error())
+		}
+
+		authClient = &http.Client{Transport: app}
+		identity = fmt.Sprintf("app:%d", cfg.Github.App.ID)
+	} else {
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+
+		ts := oauth2.StaticTokenSource(
+			&oauth2.Token{
+				AccessToken: cfg.Github.Token,
+			},
+		)
+
+		authClient = oauth2.NewClient(ctx, ts)
+		identity = "token:" + cfg.Github.Token
 	}
 
+	if cch != nil {
+		authClient.Transport = newCacheTransport(authClient.Transport, cch, cache.Key(cfg.Github.APIURL, identity))
+	}
+
+	authClient.Transport = newRateLimitTransport(authClient.Transport, rl, os.Stderr)
+	authClient.Transport = newRetryTransport(authClient.Transport, cfg.Github.Limits.Retries, verbose)
+
+	irMap := map[string]struct{}{}
 	itMap := map[string]struct{}{}
-	for i := range cfg.Github.Ignores.Topics {
-		itMap[strings.ToLower(cfg.Github.Ignores.Topics[i])] = struct{}{}
+	if cfg.Github.Ignores != nil {
+		for i := range cfg.Github.Ignores.Repos {
+			irMap[strings.ToLower(cfg.Github.Ignores.Repos[i])] = struct{}{}
+		}
+
+		for i := range cfg.Github.Ignores.Topics {
+			itMap[strings.ToLower(cfg.Github.Ignores.Topics[i])] = struct{}{}
+		}
+	}
+
+	ghClient := github.NewClient(authClient)
+	if cfg.Github.APIURL != "" {
+		ghClient, err = github.NewEnterpriseClient(cfg.Github.APIURL, cfg.Github.APIURL, authClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create enterprise client: %v", err.Error())
+		}
 	}
 
 	return &Client{
-		cfg:      cfg,
-		ghClient: github.NewClient(oauth2.NewClient(ctx, ts)),
-		rate:     rl,
+		cfg:        cfg,
+		ghClient:   ghClient,
+		httpClient: httpClient,
+		rate:       rl,
+		verbose:    verbose,
 
 		ignoreRepoMap:  irMap,
 		ignoreTopicMap: itMap,
+
+		orgSettings:  map[string]*orgSettings{},
+		repoSettings: map[string]*repoSettings{},
+		repoStates:   map[string]*repoState{},
 	}, nil
 }
 
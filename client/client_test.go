@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gomicro/crawl"
+	"github.com/gomicro/train/config"
+)
+
+// newTestClient returns a client for a fake github enterprise host served by
+// the handler given.
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg := config.Default()
+	cfg.Github.APIURL = srv.URL + "/"
+	cfg.Github.Token = "test-token"
+	cfg.Github.Limits.RequestsPerSecond = 1000
+	cfg.Github.Limits.Burst = 1000
+	cfg.Github.Limits.Retries = 0
+
+	c, err := New(cfg, io.Discard, nil)
+	if err != nil {
+		t.Fatalf("new client: %s", err)
+	}
+
+	return c
+}
+
+// testProgress returns progress bars that are never rendered.
+func testProgress() *crawl.Progress {
+	return crawl.New(context.Background(), io.Discard)
+}
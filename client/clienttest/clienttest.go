@@ -3,22 +3,34 @@ package clienttest
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/gomicro/crawl"
+	"github.com/gomicro/train/client"
 	"github.com/google/go-github/github"
 )
 
 type ClientTest struct {
-	cfg *Config
+	cfg      *Config
+	recorder client.Recorder
+	auditor  client.Auditor
 }
 
 type Config struct {
+	AuthError         error
+	AuthStatus        *client.AuthStatus
 	BaseBranchName    string
+	Estimate          *client.CostEstimate
 	Logins            []string
 	LoginsError       error
 	Repos             []*github.Repository
 	ReposError        error
+	Teams             []string
+	TeamsError        error
 	ProcessReposError error
+	StopAfter         int
+	// Changed is the repos that have moved on since they were planned
+	Changed []string
 }
 
 func New(cfg *Config) *ClientTest {
@@ -27,6 +39,38 @@ func New(cfg *Config) *ClientTest {
 	}
 }
 
+func (ct *ClientTest) CheckAuth(context.Context) error {
+	return ct.cfg.AuthError
+}
+
+func (ct *ClientTest) GetAuthStatus(context.Context) (*client.AuthStatus, error) {
+	if ct.cfg.AuthError != nil {
+		return nil, ct.cfg.AuthError
+	}
+
+	return ct.cfg.AuthStatus, nil
+}
+
+func (ct *ClientTest) EstimateCost(context.Context, string, []*github.Repository, bool) (*client.CostEstimate, error) {
+	if ct.cfg.Estimate != nil {
+		return ct.cfg.Estimate, nil
+	}
+
+	return &client.CostEstimate{Remaining: -1}, nil
+}
+
+func (ct *ClientTest) SetAuditor(a client.Auditor) {
+	ct.auditor = a
+}
+
+func (ct *ClientTest) SetRecorder(r client.Recorder) {
+	ct.recorder = r
+}
+
+func (ct *ClientTest) RevokeToken(context.Context, string, string) error {
+	return ct.cfg.AuthError
+}
+
 func (ct *ClientTest) GetBaseBranchName() string {
 	if ct.cfg != nil {
 		return ct.cfg.BaseBranchName
@@ -51,6 +95,30 @@ func (ct *ClientTest) GetRepos(ctx context.Context, progress *crawl.Progress, na
 	return ct.cfg.Repos, nil
 }
 
+func (ct *ClientTest) GetTeams(ctx context.Context, org string) ([]string, error) {
+	if ct.cfg.TeamsError != nil {
+		return nil, ct.cfg.TeamsError
+	}
+
+	return ct.cfg.Teams, nil
+}
+
+func (ct *ClientTest) GetTeamRepos(ctx context.Context, progress *crawl.Progress, org, slug, permission string) ([]*github.Repository, error) {
+	if ct.cfg.ReposError != nil {
+		return nil, ct.cfg.ReposError
+	}
+
+	return ct.cfg.Repos, nil
+}
+
+func (ct *ClientTest) SearchRepos(ctx context.Context, progress *crawl.Progress, query string) ([]*github.Repository, error) {
+	if ct.cfg.ReposError != nil {
+		return nil, ct.cfg.ReposError
+	}
+
+	return ct.cfg.Repos, nil
+}
+
 func (ct *ClientTest) ProcessRepos(ctx context.Context, progress *crawl.Progress, repos []*github.Repository, dryRun bool) ([]string, error) {
 	if ct.cfg.ProcessReposError != nil {
 		return nil, ct.cfg.ProcessReposError
@@ -59,12 +127,29 @@ func (ct *ClientTest) ProcessRepos(ctx context.Context, progress *crawl.Progress
 	urls := make([]string, len(ct.cfg.Repos))
 
 	for i, r := range repos {
+		if ct.cfg.StopAfter > 0 && i >= ct.cfg.StopAfter {
+			return urls, client.ErrInterrupted
+		}
+
 		name := r.GetName()
 		owner := r.GetOwner().GetLogin()
 		head := r.GetDefaultBranch()
 
 		if !dryRun {
-			urls = append(urls, fmt.Sprintf("https://github.com/%s/%s/pull/%d", owner, name, i))
+			url := fmt.Sprintf("https://github.com/%s/%s/pull/%d", owner, name, i)
+			urls = append(urls, url)
+
+			if ct.recorder != nil {
+				err := ct.recorder.Record(client.Result{
+					Repo:   fmt.Sprintf("%s/%s", owner, name),
+					Status: client.ResultDone,
+					URL:    url,
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+
 			continue
 		}
 
@@ -77,3 +162,72 @@ func (ct *ClientTest) ProcessRepos(ctx context.Context, progress *crawl.Progress
 func (ct *ClientTest) ReleaseRepos(ctx context.Context, progress *crawl.Progress, repos []*github.Repository, dryRun bool) ([]string, error) {
 	return nil, nil
 }
+
+func (ct *ClientTest) PlanCreate(ctx context.Context, progress *crawl.Progress, repos []*github.Repository) ([]*client.Change, error) {
+	var changes []*client.Change
+	for _, r := range repos {
+		changes = append(changes, &client.Change{
+			Action:  client.ChangeCreate,
+			Repo:    fmt.Sprintf("%s/%s", r.GetOwner().GetLogin(), r.GetName()),
+			Head:    r.GetDefaultBranch(),
+			Base:    ct.cfg.BaseBranchName,
+			HeadSHA: "1111111111",
+			BaseSHA: "2222222222",
+			Title:   "Release",
+			Commits: 2,
+			Summary: "1 added, 1 fixed",
+		})
+	}
+
+	return changes, nil
+}
+
+func (ct *ClientTest) PlanRelease(ctx context.Context, progress *crawl.Progress, repos []*github.Repository) ([]*client.Change, error) {
+	var changes []*client.Change
+	for i, r := range repos {
+		repo := fmt.Sprintf("%s/%s", r.GetOwner().GetLogin(), r.GetName())
+
+		changes = append(changes, &client.Change{
+			Action:         client.ChangeMerge,
+			Repo:           repo,
+			Number:         i,
+			URL:            fmt.Sprintf("https://github.com/%s/pull/%d", repo, i),
+			Head:           r.GetDefaultBranch(),
+			Base:           ct.cfg.BaseBranchName,
+			HeadSHA:        "1111111111",
+			BaseSHA:        "2222222222",
+			MergeableState: "clean",
+		})
+	}
+
+	return changes, nil
+}
+
+func (ct *ClientTest) Apply(ctx context.Context, progress *crawl.Progress, changes []*client.Change) ([]string, error) {
+	changed := map[string]struct{}{}
+	for _, r := range ct.cfg.Changed {
+		changed[r] = struct{}{}
+	}
+
+	urls := []string{}
+	var refused []string
+	for i, ch := range changes {
+		if _, ok := changed[ch.Repo]; ok {
+			refused = append(refused, ch.Repo)
+			continue
+		}
+
+		url := ch.URL
+		if url == "" {
+			url = fmt.Sprintf("https://github.com/%s/pull/%d", ch.Repo, i)
+		}
+
+		urls = append(urls, url)
+	}
+
+	if len(refused) > 0 {
+		return urls, fmt.Errorf("%w, refused: %s", client.ErrStale, strings.Join(refused, ", "))
+	}
+
+	return urls, nil
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionRelease = "release"
+)
+
+var (
+	ErrUnknownAction  = errors.New("unknown action")
+	ErrBudgetExceeded = errors.New("run would exhaust the api rate limit")
+)
+
+// CostEstimate represents how many api calls a run is expected to make, along
+// with the rate limit budget available to it.
+type CostEstimate struct {
+	Calls int
+	// Remaining is the number of calls left before the rate limit resets, or
+	// -1 if it could not be determined
+	Remaining int
+	Limit     int
+	Reset     time.Time
+}
+
+// Known reports whether the remaining budget could be determined.
+func (e *CostEstimate) Known() bool {
+	return e.Remaining >= 0
+}
+
+// Exceeds reports whether the run is expected to use more calls than remain
+// before the rate limit resets.
+func (e *CostEstimate) Exceeds() bool {
+	return e.Known() && e.Calls > e.Remaining
+}
+
+func (e *CostEstimate) String() string {
+	if !e.Known() {
+		return fmt.Sprintf("%d api calls, remaining rate limit unknown", e.Calls)
+	}
+
+	return fmt.Sprintf("%d api calls, %d of %d remaining until %s", e.Calls, e.Remaining, e.Limit, e.Reset.Local().Format(time.Kitchen))
+}
+
+// EstimateCost estimates the api calls the action given will make against the
+// repos when run live, using what is already known of them, and fetches the
+// remaining rate limit to compare it against. A run confirming its changes
+// first plans them and then applies them, checking each repo again, which
+// takes more calls. Checking the rate limit does not count against it.
+func (c *Client) EstimateCost(ctx context.Context, action string, repos []*github.Repository, confirm bool) (*CostEstimate, error) {
+	calls := 0
+	owners := map[string]struct{}{}
+	for _, repo := range repos {
+		owner := strings.ToLower(repo.GetOwner().GetLogin())
+		if _, ok := c.orgSettings[owner]; !ok {
+			owners[owner] = struct{}{}
+		}
+
+		key := strings.ToLower(fmt.Sprintf("%v/%v", repo.GetOwner().GetLogin(), repo.GetName()))
+		if _, ok := c.repoSettings[key]; !ok {
+			calls++
+		}
+
+		state := c.repoStates[key]
+		if state != nil && !state.prKnown {
+			state = nil
+		}
+
+		switch {
+		case action == ActionCreate && confirm:
+			calls += planCreateCost(state)
+		case action == ActionCreate:
+			calls += createCost(state)
+		case action == ActionRelease && confirm:
+			calls += planReleaseCost(state)
+		case action == ActionRelease:
+			calls += releaseCost(state)
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAction, action)
+		}
+	}
+
+	// org settings files are fetched once per owner
+	calls += len(owners)
+
+	est := &CostEstimate{
+		Calls:     calls,
+		Remaining: -1,
+	}
+
+	c.rate.Wait(ctx) //nolint: errcheck
+	limits, _, err := c.ghClient.RateLimits(ctx)
+	if err != nil {
+		fmt.Fprintf(c.verbose, "checking rate limit: %s\n", err)
+		return est, nil
+	}
+
+	if core := limits.GetCore(); core != nil {
+		est.Remaining = core.Remaining
+		est.Limit = core.Limit
+		est.Reset = core.Reset.Time
+	}
+
+	return est, nil
+}
+
+// createCost is the number of calls processing a repo for release PRs takes,
+// as an upper bound.
+func createCost(state *repoState) int {
+	if state == nil {
+		// branch, pull requests, comparison, and creating or editing the PR
+		return 4
+	}
+
+	if !state.branchExists || (state.pr == nil && state.aheadBy == 0) {
+		return 0
+	}
+
+	// comparison, and creating or editing the PR
+	return 2
+}
+
+// releaseCost is the number of calls releasing a repo takes, as an upper
+// bound.
+func releaseCost(state *repoState) int {
+	if state == nil {
+		// pull requests, the PR's mergeable state, and merging it
+		return 3
+	}
+
+	if state.pr == nil {
+		return 0
+	}
+
+	// the PR's mergeable state, and merging it
+	return 2
+}
+
+// planCreateCost is the number of calls planning the release PR of a repo and
+// applying it takes, as an upper bound.
+func planCreateCost(state *repoState) int {
+	if state == nil {
+		// both branches, pull requests, and comparison to plan, then both
+		// branches, the PR, and editing it to apply
+		return 8
+	}
+
+	if !state.branchExists {
+		// both branches, the release branch not being found
+		return 2
+	}
+
+	if state.pr == nil && state.aheadBy == 0 {
+		// both branches, and comparison finding nothing to release
+		return 3
+	}
+
+	// both branches and comparison to plan, then both branches, the PR, and
+	// editing it to apply
+	return 7
+}
+
+// planReleaseCost is the number of calls planning the merge of a repo's
+// release PR and applying it takes, as an upper bound.
+func planReleaseCost(state *repoState) int {
+	if state == nil {
+		// pull requests, the PR, and comparison to plan, then the PR and
+		// merging it to apply
+		return 5
+	}
+
+	if state.pr == nil {
+		return 0
+	}
+
+	// the PR and comparison to plan, then the PR and merging it to apply
+	return 4
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+func TestEstimateCost(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	repos := []*github.Repository{
+		{Name: github.String("steward"), Owner: &github.User{Login: github.String("gomicro")}},
+		{Name: github.String("penname"), Owner: &github.User{Login: github.String("gomicro")}},
+	}
+
+	g.Describe("EstimateCost", func() {
+		var mux *http.ServeMux
+
+		g.BeforeEach(func() {
+			mux = http.NewServeMux()
+			mux.HandleFunc("/rate_limit", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"resources":{"core":{"limit":5000,"remaining":15,"reset":1700000000}}}`)
+			})
+		})
+
+		g.It("should estimate processing the repos directly", func() {
+			c := newTestClient(t, mux)
+
+			est, err := c.EstimateCost(context.Background(), ActionCreate, repos, false)
+			Expect(err).To(BeNil())
+			// a settings file and four calls per repo, and the org settings file
+			Expect(est.Calls).To(Equal(11))
+			Expect(est.Remaining).To(Equal(15))
+			Expect(est.Exceeds()).To(BeFalse())
+
+			est, err = c.EstimateCost(context.Background(), ActionRelease, repos, false)
+			Expect(err).To(BeNil())
+			Expect(est.Calls).To(Equal(9))
+		})
+
+		g.It("should estimate planning the changes and applying them when confirming", func() {
+			c := newTestClient(t, mux)
+
+			est, err := c.EstimateCost(context.Background(), ActionCreate, repos, true)
+			Expect(err).To(BeNil())
+			// a settings file and eight calls per repo, and the org settings file
+			Expect(est.Calls).To(Equal(19))
+			Expect(est.Exceeds()).To(BeTrue())
+
+			est, err = c.EstimateCost(context.Background(), ActionRelease, repos, true)
+			Expect(err).To(BeNil())
+			Expect(est.Calls).To(Equal(13))
+		})
+
+		g.It("should use what is known of the repos", func() {
+			c := newTestClient(t, mux)
+			c.repoStates["gomicro/steward"] = &repoState{branchExists: true, prKnown: true, aheadBy: 2}
+			c.repoStates["gomicro/penname"] = &repoState{branchExists: true, prKnown: true}
+
+			est, err := c.EstimateCost(context.Background(), ActionCreate, repos, true)
+			Expect(err).To(BeNil())
+			Expect(est.Calls).To(Equal(1 + 7 + 1 + 3 + 1))
+
+			est, err = c.EstimateCost(context.Background(), ActionRelease, repos, true)
+			Expect(err).To(BeNil())
+			Expect(est.Calls).To(Equal(1 + 0 + 1 + 0 + 1))
+		})
+
+		g.It("should refuse an unknown action", func() {
+			c := newTestClient(t, mux)
+
+			_, err := c.EstimateCost(context.Background(), "delete", repos, true)
+			Expect(err).To(MatchError(ErrUnknownAction))
+		})
+	})
+}
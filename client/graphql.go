@@ -0,0 +1,329 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gomicro/crawl"
+	"github.com/gomicro/crawl/bar"
+	"github.com/google/go-github/github"
+)
+
+// graphqlPageSize is kept small, as each repo pulls in its release branch,
+// comparison, and pull requests along with it.
+const graphqlPageSize = 50
+
+var ErrGraphQL = errors.New("graphql")
+
+const reposQuery = `query($owner: String!, $first: Int!, $cursor: String, $ref: String!, $branch: String!) {
+  repositoryOwner(login: $owner) {
+    repositories(first: $first, after: $cursor, orderBy: {field: NAME, direction: ASC}) {
+      totalCount
+      pageInfo {
+        hasNextPage
+        endCursor
+      }
+      nodes {
+        name
+        url
+        isArchived
+        owner {
+          login
+        }
+        repositoryTopics(first: 20) {
+          nodes {
+            topic {
+              name
+            }
+          }
+        }
+        defaultBranchRef {
+          name
+          compare(headRef: $branch) {
+            behindBy
+          }
+          associatedPullRequests(states: OPEN, baseRefName: $branch, first: 5) {
+            totalCount
+            nodes {
+              number
+              url
+              title
+            }
+          }
+        }
+        release: ref(qualifiedName: $ref) {
+          name
+        }
+      }
+    }
+  }
+}`
+
+// repoState is what is known of a repo's release ahead of processing it,
+// fetched in bulk so it need not be fetched per repo.
+type repoState struct {
+	// releaseBranch is the branch the state was fetched for
+	releaseBranch string
+	branchExists  bool
+	// aheadBy is the number of commits on the default branch missing from the
+	// release branch, or -1 if it is not known
+	aheadBy int
+	// pr is the open release PR, or nil if there is none
+	pr *github.PullRequest
+	// prKnown is whether every open PR from the default branch to the
+	// release branch was fetched, so that a missing pr means there is none
+	prKnown bool
+}
+
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphqlError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type graphqlRepo struct {
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	IsArchived bool   `json:"isArchived"`
+	Owner      struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string `json:"name"`
+			} `json:"topic"`
+		} `json:"nodes"`
+	} `json:"repositoryTopics"`
+	DefaultBranchRef *struct {
+		Name    string `json:"name"`
+		Compare *struct {
+			BehindBy int `json:"behindBy"`
+		} `json:"compare"`
+		// the open PRs with the default branch as their head
+		AssociatedPullRequests struct {
+			TotalCount int `json:"totalCount"`
+			Nodes      []struct {
+				Number int    `json:"number"`
+				URL    string `json:"url"`
+				Title  string `json:"title"`
+			} `json:"nodes"`
+		} `json:"associatedPullRequests"`
+	} `json:"defaultBranchRef"`
+	Release *struct {
+		Name string `json:"name"`
+	} `json:"release"`
+}
+
+type reposResponse struct {
+	Data struct {
+		RepositoryOwner *struct {
+			Repositories struct {
+				TotalCount int `json:"totalCount"`
+				PageInfo   struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []graphqlRepo `json:"nodes"`
+			} `json:"repositories"`
+		} `json:"repositoryOwner"`
+	} `json:"data"`
+	Errors []graphqlError `json:"errors"`
+}
+
+// getReposGraphQL fetches the repos of an org or user in pages, along with the
+// state of each repo's release, recording the state for use when the repos are
+// processed.
+func (c *Client) getReposGraphQL(ctx context.Context, progress *crawl.Progress, name string) ([]*github.Repository, error) {
+	org, err := c.settingsForOrg(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("org settings: %w", err)
+	}
+
+	branch := org.releaseBranch
+
+	vars := map[string]interface{}{
+		"owner":  name,
+		"first":  graphqlPageSize,
+		"ref":    "refs/heads/" + branch,
+		"branch": branch,
+	}
+
+	var repoBar *bar.Bar
+	var repos []*github.Repository
+	for {
+		err := interruption(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp reposResponse
+		err = c.graphql(ctx, reposQuery, vars, &resp)
+		if err != nil {
+			return nil, err
+		}
+
+		owner := resp.Data.RepositoryOwner
+		if owner == nil {
+			if len(resp.Errors) > 0 {
+				return nil, fmt.Errorf("%w: %s", ErrGraphQL, resp.Errors[0].Message)
+			}
+
+			return nil, fmt.Errorf("%w: owner not found: %s", ErrGraphQL, name)
+		}
+
+		if repoBar == nil {
+			if owner.Repositories.TotalCount < 1 {
+				return nil, fmt.Errorf("no repos found")
+			}
+
+			theme := bar.NewThemeFromTheme(bar.DefaultTheme)
+			theme.Append(func(b *bar.Bar) string {
+				return fmt.Sprintf(" %0.2f", b.CompletedPercent())
+			})
+			theme.Prepend(func(b *bar.Bar) string {
+				return fmt.Sprintf("Fetching (%d/%d) %s", b.Current(), b.Total(), b.Elapsed())
+			})
+
+			repoBar = bar.New(theme, owner.Repositories.TotalCount)
+			progress.AddBar(repoBar)
+		}
+
+		for i := range owner.Repositories.Nodes {
+			repoBar.Incr()
+
+			repo, state := owner.Repositories.Nodes[i].convert(branch)
+
+			skip, err := c.ignored(ctx, repo)
+			if err != nil {
+				return nil, fmt.Errorf("ignores: %w", err)
+			}
+
+			if skip {
+				continue
+			}
+
+			c.repoStates[strings.ToLower(repo.GetFullName())] = state
+			repos = append(repos, repo)
+		}
+
+		if !owner.Repositories.PageInfo.HasNextPage {
+			break
+		}
+
+		vars["cursor"] = owner.Repositories.PageInfo.EndCursor
+	}
+
+	return repos, nil
+}
+
+// convert returns the repo in the form the REST api describes it, along with
+// the state of its release on the branch given.
+func (r *graphqlRepo) convert(branch string) (*github.Repository, *repoState) {
+	repo := &github.Repository{
+		Name:     github.String(r.Name),
+		FullName: github.String(fmt.Sprintf("%s/%s", r.Owner.Login, r.Name)),
+		HTMLURL:  github.String(r.URL),
+		Archived: github.Bool(r.IsArchived),
+		Owner: &github.User{
+			Login: github.String(r.Owner.Login),
+		},
+	}
+
+	for _, t := range r.RepositoryTopics.Nodes {
+		repo.Topics = append(repo.Topics, t.Topic.Name)
+	}
+
+	state := &repoState{
+		releaseBranch: branch,
+		branchExists:  r.Release != nil,
+		aheadBy:       -1,
+	}
+
+	if r.DefaultBranchRef == nil {
+		// without a default branch there is nothing to open a PR from
+		state.prKnown = true
+		return repo, state
+	}
+
+	repo.DefaultBranch = github.String(r.DefaultBranchRef.Name)
+
+	if r.DefaultBranchRef.Compare != nil {
+		state.aheadBy = r.DefaultBranchRef.Compare.BehindBy
+	}
+
+	prs := r.DefaultBranchRef.AssociatedPullRequests
+	state.prKnown = prs.TotalCount <= len(prs.Nodes)
+
+	for _, pr := range prs.Nodes {
+		state.pr = &github.PullRequest{
+			Number:  github.Int(pr.Number),
+			HTMLURL: github.String(pr.URL),
+			Title:   github.String(pr.Title),
+			Base: &github.PullRequestBranch{
+				Ref:  github.String(branch),
+				Repo: repo,
+			},
+		}
+
+		break
+	}
+
+	return repo, state
+}
+
+// stateFor returns the state fetched in bulk for a repo's release to the
+// branch given, or nil if it is not known, or not known for certain, and must
+// be fetched directly.
+func (c *Client) stateFor(repo *github.Repository, branch string) *repoState {
+	key := strings.ToLower(fmt.Sprintf("%v/%v", repo.GetOwner().GetLogin(), repo.GetName()))
+
+	s, ok := c.repoStates[key]
+	if !ok || s.releaseBranch != branch || !s.prKnown {
+		return nil
+	}
+
+	return s
+}
+
+// graphql posts a query to the graphql api, decoding the response into out.
+func (c *Client) graphql(ctx context.Context, query string, vars map[string]interface{}, out interface{}) error {
+	req, err := c.ghClient.NewRequest(http.MethodPost, c.graphqlURL(), &graphqlRequest{
+		Query:     query,
+		Variables: vars,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrGraphQL, err)
+	}
+
+	c.rate.Wait(ctx) //nolint: errcheck
+	_, err = c.ghClient.Do(ctx, req, out)
+	if err != nil {
+		if _, ok := err.(*github.RateLimitError); ok {
+			return fmt.Errorf("github: hit rate limit")
+		}
+
+		return fmt.Errorf("%w: %w", ErrGraphQL, err)
+	}
+
+	return nil
+}
+
+// graphqlURL returns the graphql endpoint of the host, which enterprise hosts
+// serve beside their REST api rather than beneath it.
+func (c *Client) graphqlURL() string {
+	u := c.ghClient.BaseURL.String()
+
+	if strings.HasSuffix(u, "/api/v3/") {
+		return strings.TrimSuffix(u, "v3/") + "graphql"
+	}
+
+	return u + "graphql"
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+// graphqlRepos is a page of two repos: train, with its release PR among the
+// PRs fetched, and steward, with more open PRs from its default branch than
+// were fetched.
+const graphqlRepos = `{"data":{"repositoryOwner":{"repositories":{
+  "totalCount": 2,
+  "pageInfo": {"hasNextPage": false, "endCursor": "b"},
+  "nodes": [
+    {
+      "name": "train", "url": "https://github.com/gomicro/train", "owner": {"login": "gomicro"},
+      "defaultBranchRef": {
+        "name": "main",
+        "compare": {"behindBy": 3},
+        "associatedPullRequests": {"totalCount": 1, "nodes": [{"number": 12, "url": "https://github.com/gomicro/train/pull/12", "title": "Release"}]}
+      },
+      "release": {"name": "release"}
+    },
+    {
+      "name": "steward", "url": "https://github.com/gomicro/steward", "owner": {"login": "gomicro"},
+      "defaultBranchRef": {
+        "name": "master",
+        "compare": {"behindBy": 1},
+        "associatedPullRequests": {"totalCount": 6, "nodes": [
+          {"number": 1}, {"number": 2}, {"number": 3}, {"number": 4}, {"number": 5}
+        ]}
+      },
+      "release": {"name": "release"}
+    }
+  ]
+}}}}`
+
+func TestGraphQL(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Release State", func() {
+		var queries []string
+		var listed []string
+		var c *Client
+
+		g.BeforeEach(func() {
+			queries = nil
+			listed = nil
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+				var req graphqlRequest
+				Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+				queries = append(queries, req.Query)
+
+				fmt.Fprint(w, graphqlRepos)
+			})
+			mux.HandleFunc("/repos/gomicro/steward/pulls", func(w http.ResponseWriter, r *http.Request) {
+				listed = append(listed, r.URL.RawQuery)
+
+				fmt.Fprint(w, `[{"number":6,"html_url":"https://github.com/gomicro/steward/pull/6"}]`)
+			})
+			mux.HandleFunc("/", http.NotFound)
+
+			c = newTestClient(t, mux)
+		})
+
+		g.It("should only fetch open PRs from each repo's default branch to its release branch", func() {
+			_, err := c.getReposGraphQL(context.Background(), testProgress(), "gomicro")
+			Expect(err).To(BeNil())
+
+			Expect(queries).To(HaveLen(1))
+			Expect(queries[0]).To(ContainSubstring("associatedPullRequests(states: OPEN, baseRefName: $branch"))
+		})
+
+		g.It("should use the PR fetched in bulk when every open PR was fetched", func() {
+			repos, err := c.getReposGraphQL(context.Background(), testProgress(), "gomicro")
+			Expect(err).To(BeNil())
+			Expect(repos).To(HaveLen(2))
+
+			pr, err := c.openRelease(context.Background(), repos[0], repos[0].GetDefaultBranch(), "release")
+			Expect(err).To(BeNil())
+			Expect(pr.GetNumber()).To(Equal(12))
+			Expect(listed).To(BeEmpty())
+		})
+
+		g.It("should list the PRs directly when not every open PR was fetched", func() {
+			repos, err := c.getReposGraphQL(context.Background(), testProgress(), "gomicro")
+			Expect(err).To(BeNil())
+
+			Expect(c.stateFor(repos[1], "release")).To(BeNil())
+
+			pr, err := c.openRelease(context.Background(), repos[1], repos[1].GetDefaultBranch(), "release")
+			Expect(err).To(BeNil())
+			Expect(pr.GetNumber()).To(Equal(6))
+
+			Expect(listed).To(HaveLen(1))
+			Expect(listed[0]).To(ContainSubstring("head=master"))
+			Expect(listed[0]).To(ContainSubstring("base=release"))
+		})
+
+		g.It("should mark whether every open PR of a repo was fetched", func() {
+			repos, err := c.getReposGraphQL(context.Background(), testProgress(), "gomicro")
+			Expect(err).To(BeNil())
+
+			train := strings.ToLower(repos[0].GetFullName())
+			steward := strings.ToLower(repos[1].GetFullName())
+			Expect(c.repoStates[train].prKnown).To(BeTrue())
+			Expect(c.repoStates[steward].prKnown).To(BeFalse())
+		})
+	})
+}
@@ -9,9 +9,21 @@ import (
 
 // interface for a train client
 type Clienter interface {
+	Apply(context.Context, *crawl.Progress, []*Change) ([]string, error)
+	CheckAuth(context.Context) error
+	EstimateCost(context.Context, string, []*github.Repository, bool) (*CostEstimate, error)
+	GetAuthStatus(context.Context) (*AuthStatus, error)
 	GetBaseBranchName() string
 	GetLogins(context.Context) ([]string, error)
 	GetRepos(context.Context, *crawl.Progress, string) ([]*github.Repository, error)
+	GetTeams(context.Context, string) ([]string, error)
+	GetTeamRepos(context.Context, *crawl.Progress, string, string, string) ([]*github.Repository, error)
+	SearchRepos(context.Context, *crawl.Progress, string) ([]*github.Repository, error)
+	PlanCreate(context.Context, *crawl.Progress, []*github.Repository) ([]*Change, error)
+	PlanRelease(context.Context, *crawl.Progress, []*github.Repository) ([]*Change, error)
 	ProcessRepos(context.Context, *crawl.Progress, []*github.Repository, bool) ([]string, error)
 	ReleaseRepos(context.Context, *crawl.Progress, []*github.Repository, bool) ([]string, error)
+	RevokeToken(context.Context, string, string) error
+	SetAuditor(Auditor)
+	SetRecorder(Recorder)
 }
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrInterrupted = errors.New("run interrupted")
+
+type stopKey struct{}
+
+// WithStop returns a context carrying a channel that, once closed, asks a run
+// to stop after the repo in flight, rather than abandoning it part way as
+// cancelling the context does.
+func WithStop(ctx context.Context, stop <-chan struct{}) context.Context {
+	return context.WithValue(ctx, stopKey{}, stop)
+}
+
+// Stopped returns a channel closed once the run has been asked to stop, or
+// nil if it cannot be, which blocks forever when received from.
+func Stopped(ctx context.Context) <-chan struct{} {
+	stop, _ := ctx.Value(stopKey{}).(<-chan struct{})
+	return stop
+}
+
+// interruption returns an error if the run has been asked to stop, or its
+// context is done.
+func interruption(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInterrupted, err)
+	}
+
+	select {
+	case <-Stopped(ctx):
+		return ErrInterrupted
+	default:
+		return nil
+	}
+}
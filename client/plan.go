@@ -0,0 +1,545 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gomicro/crawl"
+	"github.com/gomicro/crawl/bar"
+	"github.com/google/go-github/github"
+)
+
+const (
+	ChangeCreate = "create"
+	ChangeEdit   = "edit"
+	ChangeMerge  = "merge"
+)
+
+var (
+	ErrUnknownChange = errors.New("unknown change")
+	ErrStale         = errors.New("changed since planned")
+)
+
+// Change represents a single PR create, edit, or merge planned for a repo,
+// along with the state of the repo it was planned against.
+type Change struct {
+	Action string `json:"action"`
+	Repo   string `json:"repo"`
+	Number int    `json:"number,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Head   string `json:"head"`
+	Base   string `json:"base"`
+	// HeadSHA and BaseSHA are the commits the head and base were at when the
+	// change was planned
+	HeadSHA     string `json:"head_sha"`
+	BaseSHA     string `json:"base_sha"`
+	Title       string `json:"title,omitempty"`
+	Body        string `json:"body,omitempty"`
+	MergeMethod string `json:"merge_method,omitempty"`
+	// Commits is the number of commits being released, and Summary how many
+	// of each kind of change their messages describe
+	Commits        int    `json:"commits,omitempty"`
+	Summary        string `json:"summary,omitempty"`
+	MergeableState string `json:"mergeable_state,omitempty"`
+}
+
+// Ready reports whether the change may be made, which for a merge requires
+// the PR to be mergeable.
+func (ch *Change) Ready() bool {
+	return ch.Action != ChangeMerge || strings.ToLower(ch.MergeableState) == "clean"
+}
+
+// String returns a short description of the change.
+func (ch *Change) String() string {
+	repo := ch.Repo
+	if ch.Number != 0 {
+		repo = fmt.Sprintf("%s#%d", ch.Repo, ch.Number)
+	}
+
+	return fmt.Sprintf("%s %s %s (%s) into %s (%s)", ch.Action, repo, ch.Head, ShortSHA(ch.HeadSHA), ch.Base, ShortSHA(ch.BaseSHA))
+}
+
+func (ch *Change) repo() *github.Repository {
+	owner, name, _ := strings.Cut(ch.Repo, "/")
+
+	return &github.Repository{
+		Name:  github.String(name),
+		Owner: &github.User{Login: github.String(owner)},
+	}
+}
+
+// PlanCreate returns the release PRs that creating releases for the repos
+// would create or edit, without making any changes.
+func (c *Client) PlanCreate(ctx context.Context, progress *crawl.Progress, repos []*github.Repository) ([]*Change, error) {
+	return c.plan(ctx, progress, repos, c.planCreate)
+}
+
+// PlanRelease returns the release PRs that releasing the repos would merge,
+// without making any changes.
+func (c *Client) PlanRelease(ctx context.Context, progress *crawl.Progress, repos []*github.Repository) ([]*Change, error) {
+	return c.plan(ctx, progress, repos, c.planRelease)
+}
+
+func (c *Client) plan(ctx context.Context, progress *crawl.Progress, repos []*github.Repository, planRepo func(context.Context, *github.Repository) (*Change, error)) ([]*Change, error) {
+	var changes []*Change
+	if len(repos) < 1 {
+		return changes, nil
+	}
+
+	name := repos[0].GetName()
+	owner := repos[0].GetOwner().GetLogin()
+	appendStr := fmt.Sprintf("\nCurrent Repo: %v/%v", owner, name)
+
+	theme := bar.NewThemeFromTheme(bar.DefaultTheme)
+	theme.Append(func(b *bar.Bar) string {
+		return fmt.Sprintf(" %0.2f", b.CompletedPercent())
+	})
+	theme.Append(func(b *bar.Bar) string {
+		return appendStr
+	})
+	theme.Prepend(func(b *bar.Bar) string {
+		return fmt.Sprintf("Planning (%d/%d) %s", b.Current(), b.Total(), b.Elapsed())
+	})
+
+	repoBar := bar.New(theme, len(repos))
+	progress.AddBar(repoBar)
+
+	for _, repo := range repos {
+		err := interruption(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		name = repo.GetName()
+		owner = repo.GetOwner().GetLogin()
+		appendStr = fmt.Sprintf("\nCurrent Repo: %v/%v", owner, name)
+
+		ch, err := planRepo(ctx, repo)
+		if err != nil {
+			if errors.Is(err, ErrNoBranch) || errors.Is(err, ErrNoCommits) || errors.Is(err, ErrRepoDisabled) {
+				fmt.Fprintf(c.verbose, "not planning %v/%v: %s\n", owner, name, err)
+
+				err = c.record(repo, ResultSkipped, "", err.Error())
+				if err != nil {
+					return nil, err
+				}
+
+				repoBar.Incr()
+				continue
+			}
+
+			c.record(repo, ResultFailed, "", err.Error()) //nolint: errcheck
+
+			return nil, fmt.Errorf("plan %v/%v: %w", owner, name, err)
+		}
+
+		if ch != nil {
+			changes = append(changes, ch)
+		}
+
+		repoBar.Incr()
+	}
+
+	appendStr = ""
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Repo < changes[j].Repo
+	})
+
+	return changes, nil
+}
+
+// planCreate returns the change that would open or refresh the release PR of
+// a repo, pinned to the commits its branches are at now.
+func (c *Client) planCreate(ctx context.Context, repo *github.Repository) (*Change, error) {
+	name := repo.GetName()
+	owner := repo.GetOwner().GetLogin()
+	head := repo.GetDefaultBranch()
+
+	settings, err := c.settingsFor(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if !settings.enabled {
+		return nil, ErrRepoDisabled
+	}
+
+	base := settings.releaseBranch
+
+	headSHA, err := c.branchSHA(ctx, owner, name, head)
+	if err != nil {
+		return nil, err
+	}
+
+	baseSHA, err := c.branchSHA(ctx, owner, name, base)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := c.openRelease(ctx, repo, head, base)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, commits, err := c.createChangeLog(ctx, owner, name, base, headSHA)
+	if err != nil && (pr == nil || !errors.Is(err, ErrNoCommits)) {
+		return nil, err
+	}
+
+	ch := &Change{
+		Action:  ChangeCreate,
+		Repo:    fmt.Sprintf("%v/%v", owner, name),
+		Head:    head,
+		Base:    base,
+		HeadSHA: headSHA,
+		BaseSHA: baseSHA,
+		Title:   "Release",
+		Body:    prBody(prBodyTemplate, changes),
+		Commits: commits,
+		Summary: summarize(changes),
+	}
+
+	if pr != nil {
+		ch.Action = ChangeEdit
+		ch.Number = pr.GetNumber()
+		ch.URL = pr.GetHTMLURL()
+		ch.MergeableState = pr.GetMergeableState()
+	}
+
+	return ch, nil
+}
+
+// planRelease returns the change that would merge the release PR of a repo,
+// pinned to the commits the PR is at now. Repos without a release PR have
+// nothing to plan, and a PR not ready to merge is planned but not Ready.
+func (c *Client) planRelease(ctx context.Context, repo *github.Repository) (*Change, error) {
+	name := repo.GetName()
+	owner := repo.GetOwner().GetLogin()
+
+	settings, err := c.settingsFor(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if !settings.enabled {
+		return nil, ErrRepoDisabled
+	}
+
+	pr, err := c.openRelease(ctx, repo, repo.GetDefaultBranch(), settings.releaseBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	if pr == nil {
+		return nil, nil
+	}
+
+	c.rate.Wait(ctx) //nolint: errcheck
+	pr, _, err = c.ghClient.PullRequests.Get(ctx, owner, name, pr.GetNumber())
+	if err != nil {
+		return nil, fmt.Errorf("check mergeable: %w", err)
+	}
+
+	// the changelog only informs whoever reviews the change, so the merge is
+	// still planned without it
+	changes, commits, _ := c.createChangeLog(ctx, owner, name, pr.GetBase().GetRef(), pr.GetHead().GetSHA())
+
+	return &Change{
+		Action:         ChangeMerge,
+		Repo:           fmt.Sprintf("%v/%v", owner, name),
+		Number:         pr.GetNumber(),
+		URL:            pr.GetHTMLURL(),
+		Head:           pr.GetHead().GetRef(),
+		Base:           pr.GetBase().GetRef(),
+		HeadSHA:        pr.GetHead().GetSHA(),
+		BaseSHA:        pr.GetBase().GetSHA(),
+		MergeMethod:    settings.mergeMethod,
+		Commits:        commits,
+		Summary:        summarize(changes),
+		MergeableState: pr.GetMergeableState(),
+	}, nil
+}
+
+// Apply makes exactly the changes given. A change is refused if its repo has
+// moved on from the commits it was planned against, and the rest are carried
+// on with.
+func (c *Client) Apply(ctx context.Context, progress *crawl.Progress, changes []*Change) ([]string, error) {
+	urls := []string{}
+	if len(changes) < 1 {
+		return urls, nil
+	}
+
+	appendStr := fmt.Sprintf("\nCurrent Repo: %v", changes[0].Repo)
+
+	theme := bar.NewThemeFromTheme(bar.DefaultTheme)
+	theme.Append(func(b *bar.Bar) string {
+		return fmt.Sprintf(" %0.2f", b.CompletedPercent())
+	})
+	theme.Append(func(b *bar.Bar) string {
+		return appendStr
+	})
+	theme.Prepend(func(b *bar.Bar) string {
+		return fmt.Sprintf("Applying (%d/%d) %s", b.Current(), b.Total(), b.Elapsed())
+	})
+
+	repoBar := bar.New(theme, len(changes))
+	progress.AddBar(repoBar)
+
+	var refused []string
+	for _, ch := range changes {
+		err := interruption(ctx)
+		if err != nil {
+			sort.Strings(urls)
+			return urls, err
+		}
+
+		appendStr = fmt.Sprintf("\nCurrent Repo: %v", ch.Repo)
+		repo := ch.repo()
+
+		url, err := c.applyChange(ctx, ch)
+		if err != nil {
+			if errors.Is(err, ErrStale) {
+				reason := strings.TrimPrefix(err.Error(), ErrStale.Error()+": ")
+				refused = append(refused, fmt.Sprintf("%s (%s)", ch.Repo, reason))
+
+				err = c.record(repo, ResultSkipped, ch.URL, err.Error())
+				if err != nil {
+					sort.Strings(urls)
+					return urls, err
+				}
+
+				repoBar.Incr()
+				continue
+			}
+
+			c.record(repo, ResultFailed, ch.URL, err.Error()) //nolint: errcheck
+
+			sort.Strings(urls)
+			return urls, fmt.Errorf("apply %v: %w", ch.Repo, err)
+		}
+
+		urls = append(urls, url)
+
+		err = c.record(repo, ResultDone, url, "")
+		if err != nil {
+			sort.Strings(urls)
+			return urls, err
+		}
+
+		repoBar.Incr()
+	}
+
+	appendStr = ""
+
+	sort.Strings(urls)
+
+	if len(refused) > 0 {
+		sort.Strings(refused)
+		return urls, fmt.Errorf("%w, refused: %s", ErrStale, strings.Join(refused, ", "))
+	}
+
+	return urls, nil
+}
+
+func (c *Client) applyChange(ctx context.Context, ch *Change) (string, error) {
+	owner, name, _ := strings.Cut(ch.Repo, "/")
+
+	switch ch.Action {
+	case ChangeCreate:
+		err := c.checkBranches(ctx, owner, name, ch)
+		if err != nil {
+			return "", err
+		}
+
+		c.rate.Wait(ctx) //nolint: errcheck
+		pr, _, createErr := c.ghClient.PullRequests.Create(ctx, owner, name, &github.NewPullRequest{
+			Title:               github.String(ch.Title),
+			Head:                github.String(ch.Head),
+			Base:                github.String(ch.Base),
+			Body:                github.String(ch.Body),
+			MaintainerCanModify: github.Bool(true),
+		})
+
+		err = c.audit(ctx, AuditEvent{
+			Repo:      ch.Repo,
+			Action:    ChangeCreate,
+			Number:    pr.GetNumber(),
+			BeforeSHA: ch.BaseSHA,
+			AfterSHA:  ch.BaseSHA,
+			HeadSHA:   ch.HeadSHA,
+		}, createErr)
+		if err != nil {
+			return "", err
+		}
+
+		if createErr != nil {
+			return "", fmt.Errorf("create pr: %w", createErr)
+		}
+
+		return pr.GetHTMLURL(), nil
+
+	case ChangeEdit:
+		err := c.checkBranches(ctx, owner, name, ch)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = c.checkPR(ctx, owner, name, ch)
+		if err != nil {
+			return "", err
+		}
+
+		c.rate.Wait(ctx) //nolint: errcheck
+		pr, _, editErr := c.ghClient.PullRequests.Edit(ctx, owner, name, ch.Number, &github.PullRequest{
+			Title: github.String(ch.Title),
+			Body:  github.String(ch.Body),
+		})
+
+		err = c.audit(ctx, AuditEvent{
+			Repo:      ch.Repo,
+			Action:    ChangeEdit,
+			Number:    ch.Number,
+			BeforeSHA: ch.BaseSHA,
+			AfterSHA:  ch.BaseSHA,
+			HeadSHA:   ch.HeadSHA,
+		}, editErr)
+		if err != nil {
+			return "", err
+		}
+
+		if editErr != nil {
+			return "", fmt.Errorf("edit pr: %w", editErr)
+		}
+
+		return pr.GetHTMLURL(), nil
+
+	case ChangeMerge:
+		pr, err := c.checkPR(ctx, owner, name, ch)
+		if err != nil {
+			return "", err
+		}
+
+		if pr.GetBase().GetSHA() != ch.BaseSHA {
+			return "", fmt.Errorf("%w: %s is at %s, planned at %s", ErrStale, ch.Base, ShortSHA(pr.GetBase().GetSHA()), ShortSHA(ch.BaseSHA))
+		}
+
+		if strings.ToLower(pr.GetMergeableState()) != "clean" {
+			return "", fmt.Errorf("%w: no longer mergeable", ErrStale)
+		}
+
+		opts := &github.PullRequestOptions{
+			MergeMethod: ch.MergeMethod,
+			SHA:         ch.HeadSHA,
+		}
+
+		c.rate.Wait(ctx) //nolint: errcheck
+		res, _, err := c.ghClient.PullRequests.Merge(ctx, owner, name, ch.Number, "release automerged by train", opts)
+
+		err = mergeError(res, err)
+
+		auditErr := c.audit(ctx, mergeEvent(ch.repo(), pr, res), err)
+		if auditErr != nil {
+			return "", auditErr
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("merge: %w", err)
+		}
+
+		return pr.GetHTMLURL(), nil
+
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownChange, ch.Action)
+	}
+}
+
+// checkBranches returns ErrStale if the head or base of a change has moved on
+// from the commit it was planned against.
+func (c *Client) checkBranches(ctx context.Context, owner, name string, ch *Change) error {
+	for _, b := range []struct{ branch, sha string }{{ch.Head, ch.HeadSHA}, {ch.Base, ch.BaseSHA}} {
+		sha, err := c.branchSHA(ctx, owner, name, b.branch)
+		if err != nil {
+			return err
+		}
+
+		if sha != b.sha {
+			return fmt.Errorf("%w: %s is at %s, planned at %s", ErrStale, b.branch, ShortSHA(sha), ShortSHA(b.sha))
+		}
+	}
+
+	return nil
+}
+
+// checkPR returns the PR of a change, or ErrStale if it has been closed or
+// its head has moved on from the commit it was planned against.
+func (c *Client) checkPR(ctx context.Context, owner, name string, ch *Change) (*github.PullRequest, error) {
+	c.rate.Wait(ctx) //nolint: errcheck
+	pr, _, err := c.ghClient.PullRequests.Get(ctx, owner, name, ch.Number)
+	if err != nil {
+		return nil, fmt.Errorf("get pr: %w", err)
+	}
+
+	if pr.GetState() != "open" {
+		return nil, fmt.Errorf("%w: #%d is %s", ErrStale, ch.Number, pr.GetState())
+	}
+
+	if pr.GetHead().GetSHA() != ch.HeadSHA {
+		return nil, fmt.Errorf("%w: #%d head is at %s, planned at %s", ErrStale, ch.Number, ShortSHA(pr.GetHead().GetSHA()), ShortSHA(ch.HeadSHA))
+	}
+
+	return pr, nil
+}
+
+// branchSHA returns the commit a branch is at, or ErrNoBranch if the repo has
+// no such branch.
+func (c *Client) branchSHA(ctx context.Context, owner, name, branch string) (string, error) {
+	c.rate.Wait(ctx) //nolint: errcheck
+	b, resp, err := c.ghClient.Repositories.GetBranch(ctx, owner, name, branch)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("%w: %s", ErrNoBranch, branch)
+		}
+
+		return "", fmt.Errorf("get branch: %w", err)
+	}
+
+	return b.GetCommit().GetSHA(), nil
+}
+
+// openRelease returns the open release PR of a repo, or nil if there is none.
+func (c *Client) openRelease(ctx context.Context, repo *github.Repository, head, base string) (*github.PullRequest, error) {
+	if state := c.stateFor(repo, base); state != nil {
+		return state.pr, nil
+	}
+
+	opts := &github.PullRequestListOptions{
+		Head: head,
+		Base: base,
+	}
+
+	c.rate.Wait(ctx) //nolint: errcheck
+	prs, _, err := c.ghClient.PullRequests.List(ctx, repo.GetOwner().GetLogin(), repo.GetName(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("list prs: %w", err)
+	}
+
+	if len(prs) < 1 {
+		return nil, nil
+	}
+
+	return prs[0], nil
+}
+
+// ShortSHA returns the abbreviated form of a commit sha.
+func ShortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+
+	return sha
+}
@@ -0,0 +1,278 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+// results collects the results recorded by a client.
+type results []Result
+
+func (r *results) Record(res Result) error {
+	*r = append(*r, res)
+	return nil
+}
+
+// serveBranch serves the commit a branch of gomicro/steward is at.
+func serveBranch(mux *http.ServeMux, branch, sha string) {
+	mux.HandleFunc("/repos/gomicro/steward/branches/"+branch, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"name":%q,"commit":{"sha":%q}}`, branch, sha)
+	})
+}
+
+// servePR serves release PR 3 of gomicro/steward.
+func servePR(mux *http.ServeMux, state, headSHA, baseSHA, mergeable string) {
+	mux.HandleFunc("/repos/gomicro/steward/pulls/3", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"number":3,"state":%q,"html_url":"https://github.com/gomicro/steward/pull/3","head":{"ref":"master","sha":%q},"base":{"ref":"release","sha":%q},"mergeable_state":%q}`, state, headSHA, baseSHA, mergeable)
+	})
+}
+
+func TestApply(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Apply", func() {
+		var (
+			mux     *http.ServeMux
+			changed bool
+			rec     *results
+		)
+
+		g.BeforeEach(func() {
+			changed = false
+			rec = &results{}
+
+			mux = http.NewServeMux()
+			mux.HandleFunc("/repos/gomicro/steward/pulls", func(w http.ResponseWriter, r *http.Request) {
+				changed = true
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"number":4,"html_url":"https://github.com/gomicro/steward/pull/4"}`)
+			})
+			mux.HandleFunc("/repos/gomicro/steward/pulls/3/merge", func(w http.ResponseWriter, r *http.Request) {
+				changed = true
+				fmt.Fprint(w, `{"merged":true,"sha":"merged1"}`)
+			})
+		})
+
+		create := func() *Change {
+			return &Change{
+				Action:  ChangeCreate,
+				Repo:    "gomicro/steward",
+				Head:    "master",
+				Base:    "release",
+				HeadSHA: "head1",
+				BaseSHA: "base1",
+				Title:   "Release",
+			}
+		}
+
+		merge := func() *Change {
+			return &Change{
+				Action:         ChangeMerge,
+				Repo:           "gomicro/steward",
+				Number:         3,
+				URL:            "https://github.com/gomicro/steward/pull/3",
+				Head:           "master",
+				Base:           "release",
+				HeadSHA:        "head1",
+				BaseSHA:        "base1",
+				MergeMethod:    "merge",
+				MergeableState: "clean",
+			}
+		}
+
+		g.It("should make a change whose repo has not moved on", func() {
+			serveBranch(mux, "master", "head1")
+			serveBranch(mux, "release", "base1")
+
+			c := newTestClient(t, mux)
+			c.SetRecorder(rec)
+
+			urls, err := c.Apply(context.Background(), testProgress(), []*Change{create()})
+			Expect(err).To(BeNil())
+			Expect(urls).To(Equal([]string{"https://github.com/gomicro/steward/pull/4"}))
+			Expect(changed).To(BeTrue())
+			Expect(*rec).To(Equal(results{{Repo: "gomicro/steward", Status: ResultDone, URL: "https://github.com/gomicro/steward/pull/4"}}))
+		})
+
+		g.It("should refuse a change whose head has moved", func() {
+			serveBranch(mux, "master", "head2")
+			serveBranch(mux, "release", "base1")
+
+			c := newTestClient(t, mux)
+			c.SetRecorder(rec)
+
+			urls, err := c.Apply(context.Background(), testProgress(), []*Change{create()})
+			Expect(errors.Is(err, ErrStale)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("gomicro/steward (master is at head2, planned at head1)"))
+			Expect(urls).To(BeEmpty())
+			Expect(changed).To(BeFalse())
+			Expect(*rec).To(HaveLen(1))
+			Expect((*rec)[0].Status).To(Equal(ResultSkipped))
+		})
+
+		g.It("should refuse a change whose base has moved", func() {
+			serveBranch(mux, "master", "head1")
+			serveBranch(mux, "release", "base2")
+
+			c := newTestClient(t, mux)
+
+			_, err := c.Apply(context.Background(), testProgress(), []*Change{create()})
+			Expect(errors.Is(err, ErrStale)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("release is at base2, planned at base1"))
+			Expect(changed).To(BeFalse())
+		})
+
+		g.It("should refuse an edit to a PR that has been closed", func() {
+			serveBranch(mux, "master", "head1")
+			serveBranch(mux, "release", "base1")
+			servePR(mux, "closed", "head1", "base1", "clean")
+
+			ch := create()
+			ch.Action = ChangeEdit
+			ch.Number = 3
+
+			c := newTestClient(t, mux)
+
+			_, err := c.Apply(context.Background(), testProgress(), []*Change{ch})
+			Expect(errors.Is(err, ErrStale)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("#3 is closed"))
+			Expect(changed).To(BeFalse())
+		})
+
+		g.It("should refuse a merge of a PR that has been closed", func() {
+			servePR(mux, "closed", "head1", "base1", "clean")
+
+			c := newTestClient(t, mux)
+
+			_, err := c.Apply(context.Background(), testProgress(), []*Change{merge()})
+			Expect(errors.Is(err, ErrStale)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("#3 is closed"))
+			Expect(changed).To(BeFalse())
+		})
+
+		g.It("should refuse a merge whose head has moved", func() {
+			servePR(mux, "open", "head2", "base1", "clean")
+
+			c := newTestClient(t, mux)
+
+			_, err := c.Apply(context.Background(), testProgress(), []*Change{merge()})
+			Expect(errors.Is(err, ErrStale)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("#3 head is at head2, planned at head1"))
+			Expect(changed).To(BeFalse())
+		})
+
+		g.It("should refuse a merge whose base has moved", func() {
+			servePR(mux, "open", "head1", "base2", "clean")
+
+			c := newTestClient(t, mux)
+
+			_, err := c.Apply(context.Background(), testProgress(), []*Change{merge()})
+			Expect(errors.Is(err, ErrStale)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("release is at base2, planned at base1"))
+			Expect(changed).To(BeFalse())
+		})
+
+		g.It("should refuse a merge of a PR no longer mergeable", func() {
+			servePR(mux, "open", "head1", "base1", "dirty")
+
+			c := newTestClient(t, mux)
+
+			_, err := c.Apply(context.Background(), testProgress(), []*Change{merge()})
+			Expect(errors.Is(err, ErrStale)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("no longer mergeable"))
+			Expect(changed).To(BeFalse())
+		})
+
+		g.It("should carry on with the changes after one refused", func() {
+			servePR(mux, "open", "head1", "base1", "clean")
+			mux.HandleFunc("/repos/gomicro/penname/branches/master", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"name":"master","commit":{"sha":"head9"}}`)
+			})
+
+			stale := create()
+			stale.Repo = "gomicro/penname"
+
+			c := newTestClient(t, mux)
+
+			urls, err := c.Apply(context.Background(), testProgress(), []*Change{stale, merge()})
+			Expect(errors.Is(err, ErrStale)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("gomicro/penname"))
+			Expect(urls).To(Equal([]string{"https://github.com/gomicro/steward/pull/3"}))
+			Expect(changed).To(BeTrue())
+		})
+	})
+}
+
+func TestPlan(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	repo := func(name string) *github.Repository {
+		return &github.Repository{
+			Name:          github.String(name),
+			Owner:         &github.User{Login: github.String("gomicro")},
+			DefaultBranch: github.String("master"),
+		}
+	}
+
+	g.Describe("PlanCreate", func() {
+		g.It("should record the repos it skips", func() {
+			mux := http.NewServeMux()
+			serveBranch(mux, "master", "head1")
+			serveBranch(mux, "release", "head1")
+			serveFile(mux, "/repos/gomicro/penname/contents/.train.yml", "enabled: false\n")
+			mux.HandleFunc("/repos/gomicro/steward/pulls", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[]`)
+			})
+			mux.HandleFunc("/repos/gomicro/steward/compare/release...head1", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"commits":[]}`)
+			})
+			mux.HandleFunc("/", http.NotFound)
+
+			rec := &results{}
+
+			c := newTestClient(t, mux)
+			c.SetRecorder(rec)
+
+			changes, err := c.PlanCreate(context.Background(), testProgress(), []*github.Repository{repo("steward"), repo("penname")})
+			Expect(err).To(BeNil())
+			Expect(changes).To(BeEmpty())
+			Expect(*rec).To(Equal(results{
+				{Repo: "gomicro/steward", Status: ResultSkipped, Reason: ErrNoCommits.Error()},
+				{Repo: "gomicro/penname", Status: ResultSkipped, Reason: ErrRepoDisabled.Error()},
+			}))
+		})
+
+		g.It("should skip a repo without the release branch and fail one whose branch cannot be read", func() {
+			mux := http.NewServeMux()
+			serveBranch(mux, "master", "head1")
+			serveBranchStatus(mux, "steward", http.StatusNotFound)
+			mux.HandleFunc("/repos/gomicro/penname/branches/master", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"name":"master","commit":{"sha":"head2"}}`)
+			})
+			serveBranchStatus(mux, "penname", http.StatusInternalServerError)
+			mux.HandleFunc("/", http.NotFound)
+
+			rec := &results{}
+
+			c := newTestClient(t, mux)
+			c.SetRecorder(rec)
+
+			_, err := c.PlanCreate(context.Background(), testProgress(), []*github.Repository{repo("steward"), repo("penname")})
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("plan gomicro/penname: get branch"))
+			Expect(*rec).To(HaveLen(2))
+			Expect((*rec)[0]).To(Equal(Result{Repo: "gomicro/steward", Status: ResultSkipped, Reason: "branch not found: release"}))
+			Expect((*rec)[1].Repo).To(Equal("gomicro/penname"))
+			Expect((*rec)[1].Status).To(Equal(ResultFailed))
+		})
+	})
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	headerRateRemaining = "X-RateLimit-Remaining"
+	headerRateReset     = "X-RateLimit-Reset"
+	headerRetryAfter    = "Retry-After"
+
+	// maxRateLimitWaits is how many times a single request waits out a rate
+	// limit before the response is handed back as is.
+	maxRateLimitWaits = 5
+
+	// secondaryLimitWait is used for secondary rate limits reported without a
+	// Retry-After header, as github recommends.
+	secondaryLimitWait = time.Minute
+
+	// resetPadding allows for clock drift between train and github when
+	// waiting for a primary rate limit to reset.
+	resetPadding = time.Second
+)
+
+// rateLimitTransport paces requests against the rate limit budget github
+// reports, slowing the limiter as the budget runs down and sleeping through
+// primary and secondary rate limits instead of failing.
+type rateLimitTransport struct {
+	base       http.RoundTripper
+	limiter    *rate.Limiter
+	configured rate.Limit
+	out        io.Writer
+
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+}
+
+func newRateLimitTransport(base http.RoundTripper, limiter *rate.Limiter, out io.Writer) *rateLimitTransport {
+	return &rateLimitTransport{
+		base:       base,
+		limiter:    limiter,
+		configured: limiter.Limit(),
+		out:        out,
+
+		now:   time.Now,
+		sleep: sleepContext,
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for waits := 0; ; waits++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		t.adjust(resp)
+
+		wait, retry, err := t.limited(resp)
+		if err != nil {
+			return nil, err
+		}
+
+		if wait <= 0 || waits >= maxRateLimitWaits {
+			return resp, nil
+		}
+
+		if retry && req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		fmt.Fprintf(t.out, "github: rate limit reached, waiting %v until %v\n", wait.Round(time.Second), t.now().Add(wait).Format(time.Kitchen))
+
+		if retry {
+			resp.Body.Close()
+		}
+
+		err = t.sleep(req.Context(), wait)
+		if err != nil {
+			if retry {
+				return nil, err
+			}
+
+			return resp, nil
+		}
+
+		if !retry {
+			return resp, nil
+		}
+
+		req, err = rewind(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// limited returns how long to wait before github will serve more requests,
+// and whether the request itself was refused and must be retried after.
+func (t *rateLimitTransport) limited(resp *http.Response) (time.Duration, bool, error) {
+	refused := resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests
+
+	if refused {
+		if s := resp.Header.Get(headerRetryAfter); s != "" {
+			secs, err := strconv.Atoi(s)
+			if err == nil {
+				return time.Duration(secs) * time.Second, true, nil
+			}
+		}
+	}
+
+	if resp.Header.Get(headerRateRemaining) == "0" {
+		return t.untilReset(resp), refused, nil
+	}
+
+	if !refused {
+		return 0, false, nil
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return 0, false, fmt.Errorf("read response: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+
+	msg := strings.ToLower(string(b))
+	if strings.Contains(msg, "secondary rate limit") || strings.Contains(msg, "abuse") {
+		return secondaryLimitWait, true, nil
+	}
+
+	return 0, false, nil
+}
+
+// adjust spreads the remaining budget over the time left until it resets,
+// never exceeding the configured rate.
+func (t *rateLimitTransport) adjust(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get(headerRateRemaining))
+	if err != nil || remaining < 1 {
+		return
+	}
+
+	secs := t.untilReset(resp).Seconds()
+	if secs <= 0 {
+		return
+	}
+
+	limit := rate.Limit(float64(remaining) / secs)
+	if limit > t.configured {
+		limit = t.configured
+	}
+
+	t.limiter.SetLimit(limit)
+}
+
+func (t *rateLimitTransport) untilReset(resp *http.Response) time.Duration {
+	reset, err := strconv.ParseInt(resp.Header.Get(headerRateReset), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	d := time.Unix(reset, 0).Sub(t.now())
+	if d < 0 {
+		return 0
+	}
+
+	return d + resetPadding
+}
+
+// rewind returns a copy of the request with a fresh body, so it may be sent
+// again.
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("rewind request: %w", err)
+		}
+
+		r.Body = body
+	}
+
+	return r, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+)
+
+// roundTripFunc adapts a function to a round tripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// respond returns a response with the status, headers, and body given.
+func respond(status int, headers map[string]string, body string) *http.Response {
+	resp := &http.Response{
+		StatusCode: status,
+		Status:     strconv.Itoa(status) + " " + http.StatusText(status),
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+
+	for k, v := range headers {
+		resp.Header.Set(k, v)
+	}
+
+	return resp
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Rate Limit Transport", func() {
+		var now time.Time
+		var slept []time.Duration
+		var out *bytes.Buffer
+
+		newTransport := func(responses ...*http.Response) (*rateLimitTransport, *int) {
+			calls := 0
+			base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+				resp := responses[calls]
+				calls++
+
+				return resp, nil
+			})
+
+			rt := newRateLimitTransport(base, rate.NewLimiter(10, 25), out)
+			rt.now = func() time.Time { return now }
+			rt.sleep = func(_ context.Context, d time.Duration) error {
+				slept = append(slept, d)
+				now = now.Add(d)
+
+				return nil
+			}
+
+			return rt, &calls
+		}
+
+		request := func() *http.Request {
+			req, err := http.NewRequest(http.MethodGet, "https://api.github.com/repos/gomicro/train", nil)
+			Expect(err).To(BeNil())
+
+			return req
+		}
+
+		g.BeforeEach(func() {
+			now = time.Unix(1700000000, 0)
+			slept = nil
+			out = &bytes.Buffer{}
+		})
+
+		g.It("should wait out a secondary rate limit and send the request again", func() {
+			rt, calls := newTransport(
+				respond(http.StatusForbidden, nil, `{"message":"You have exceeded a secondary rate limit."}`),
+				respond(http.StatusOK, nil, `{}`),
+			)
+
+			resp, err := rt.RoundTrip(request())
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(*calls).To(Equal(2))
+			Expect(slept).To(Equal([]time.Duration{secondaryLimitWait}))
+			Expect(out.String()).To(ContainSubstring("rate limit reached, waiting 1m0s"))
+		})
+
+		g.It("should wait as long as github asks with Retry-After", func() {
+			rt, calls := newTransport(
+				respond(http.StatusTooManyRequests, map[string]string{headerRetryAfter: "7"}, ``),
+				respond(http.StatusOK, nil, `{}`),
+			)
+
+			resp, err := rt.RoundTrip(request())
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(*calls).To(Equal(2))
+			Expect(slept).To(Equal([]time.Duration{7 * time.Second}))
+		})
+
+		g.It("should wait for a spent primary rate limit to reset and send the request again", func() {
+			reset := strconv.FormatInt(now.Add(30*time.Second).Unix(), 10)
+
+			rt, calls := newTransport(
+				respond(http.StatusForbidden, map[string]string{headerRateRemaining: "0", headerRateReset: reset}, `{"message":"API rate limit exceeded"}`),
+				respond(http.StatusOK, nil, `{}`),
+			)
+
+			resp, err := rt.RoundTrip(request())
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(*calls).To(Equal(2))
+			Expect(slept).To(Equal([]time.Duration{30*time.Second + resetPadding}))
+		})
+
+		g.It("should hand back a successful response once the spent budget resets", func() {
+			reset := strconv.FormatInt(now.Add(10*time.Second).Unix(), 10)
+
+			rt, calls := newTransport(
+				respond(http.StatusOK, map[string]string{headerRateRemaining: "0", headerRateReset: reset}, `{"name":"train"}`),
+			)
+
+			resp, err := rt.RoundTrip(request())
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(*calls).To(Equal(1))
+			Expect(slept).To(Equal([]time.Duration{10*time.Second + resetPadding}))
+
+			b, err := io.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(string(b)).To(Equal(`{"name":"train"}`))
+		})
+
+		g.It("should leave other refusals alone", func() {
+			rt, calls := newTransport(
+				respond(http.StatusForbidden, nil, `{"message":"Resource not accessible by integration"}`),
+			)
+
+			resp, err := rt.RoundTrip(request())
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+			Expect(*calls).To(Equal(1))
+			Expect(slept).To(BeEmpty())
+
+			b, err := io.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(string(b)).To(ContainSubstring("Resource not accessible"))
+		})
+
+		g.It("should give up waiting after too many limits", func() {
+			var responses []*http.Response
+			for i := 0; i <= maxRateLimitWaits; i++ {
+				responses = append(responses, respond(http.StatusTooManyRequests, map[string]string{headerRetryAfter: "1"}, ``))
+			}
+
+			rt, calls := newTransport(responses...)
+
+			resp, err := rt.RoundTrip(request())
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+			Expect(*calls).To(Equal(maxRateLimitWaits + 1))
+			Expect(slept).To(HaveLen(maxRateLimitWaits))
+		})
+
+		g.It("should stop waiting when the request is cancelled", func() {
+			rt, _ := newTransport(
+				respond(http.StatusTooManyRequests, map[string]string{headerRetryAfter: "60"}, ``),
+			)
+			rt.sleep = sleepContext
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := rt.RoundTrip(request().WithContext(ctx))
+			Expect(err).To(MatchError(context.Canceled))
+		})
+
+		g.It("should spread the remaining budget over the time until it resets", func() {
+			reset := strconv.FormatInt(now.Add(100*time.Second).Unix(), 10)
+
+			rt, _ := newTransport(
+				respond(http.StatusOK, map[string]string{headerRateRemaining: "101", headerRateReset: reset}, `{}`),
+				respond(http.StatusOK, map[string]string{headerRateRemaining: "5000", headerRateReset: reset}, `{}`),
+			)
+
+			_, err := rt.RoundTrip(request())
+			Expect(err).To(BeNil())
+			Expect(float64(rt.limiter.Limit())).To(BeNumerically("~", 1, 0.01))
+
+			_, err = rt.RoundTrip(request())
+			Expect(err).To(BeNil())
+			Expect(rt.limiter.Limit()).To(Equal(rate.Limit(10)))
+		})
+	})
+}
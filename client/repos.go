@@ -13,9 +13,29 @@ import (
 	"github.com/google/go-github/github"
 )
 
-var ErrGetBranch = errors.New("get branch")
+// ErrNoBranch is returned for a repo without the branch asked for, which is
+// skipped rather than failed, as it is not yet set up for releases.
+var ErrNoBranch = errors.New("branch not found")
 
+// GetRepos returns the repos of an org or user, less any ignored. They are
+// fetched through graphql along with the state of their releases where the
+// host supports it, and through REST otherwise.
 func (c *Client) GetRepos(ctx context.Context, progress *crawl.Progress, name string) ([]*github.Repository, error) {
+	repos, err := c.getReposGraphQL(ctx, progress, name)
+	if err == nil {
+		return repos, nil
+	}
+
+	if !errors.Is(err, ErrGraphQL) {
+		return nil, err
+	}
+
+	fmt.Fprintf(c.verbose, "falling back to rest: %s\n", err)
+
+	return c.getReposREST(ctx, progress, name)
+}
+
+func (c *Client) getReposREST(ctx context.Context, progress *crawl.Progress, name string) ([]*github.Repository, error) {
 	count := 0
 	orgFound := true
 
@@ -81,6 +101,11 @@ func (c *Client) GetRepos(ctx context.Context, progress *crawl.Progress, name st
 
 	var repos []*github.Repository
 	for {
+		err := interruption(ctx)
+		if err != nil {
+			return nil, err
+		}
+
 		var rs []*github.Repository
 		c.rate.Wait(ctx) //nolint: errcheck
 		if orgFound {
@@ -100,32 +125,12 @@ func (c *Client) GetRepos(ctx context.Context, progress *crawl.Progress, name st
 		for i := range rs {
 			repoBar.Incr()
 
-			if rs[i].GetArchived() {
-				continue
-			}
-
-			name := strings.ToLower(rs[i].GetName())
-			_, looseMatch := c.ignoreRepoMap[name]
-
-			fullName := fmt.Sprintf("%v/%v", strings.ToLower(rs[i].GetOwner().GetLogin()), name)
-			_, exactMatch := c.ignoreRepoMap[fullName]
-
-			if looseMatch || exactMatch {
-				continue
-			}
-
-			topics := rs[i].Topics
-
-			topicSkip := false
-			for _, t := range topics {
-				_, topicMatch := c.ignoreTopicMap[strings.ToLower(t)]
-				if topicMatch {
-					topicSkip = true
-					break
-				}
+			skip, err := c.ignored(ctx, rs[i])
+			if err != nil {
+				return nil, fmt.Errorf("ignores: %w", err)
 			}
 
-			if topicSkip {
+			if skip {
 				continue
 			}
 
@@ -146,6 +151,45 @@ func (c *Client) GetRepos(ctx context.Context, progress *crawl.Progress, name st
 	return repos, nil
 }
 
+// ignored reports whether a repo should be skipped, either because it is
+// archived or because it matches one of the repo or topic ignores configured
+// for the user or the repo's org.
+func (c *Client) ignored(ctx context.Context, repo *github.Repository) (bool, error) {
+	if repo.GetArchived() {
+		return true, nil
+	}
+
+	org, err := c.settingsForOrg(ctx, repo.GetOwner().GetLogin())
+	if err != nil {
+		return false, err
+	}
+
+	name := strings.ToLower(repo.GetName())
+	fullName := fmt.Sprintf("%v/%v", strings.ToLower(repo.GetOwner().GetLogin()), name)
+
+	for _, m := range []map[string]struct{}{c.ignoreRepoMap, org.ignoreRepoMap} {
+		_, looseMatch := m[name]
+		_, exactMatch := m[fullName]
+
+		if looseMatch || exactMatch {
+			return true, nil
+		}
+	}
+
+	for _, t := range repo.Topics {
+		t = strings.ToLower(t)
+
+		for _, m := range []map[string]struct{}{c.ignoreTopicMap, org.ignoreTopicMap} {
+			_, topicMatch := m[t]
+			if topicMatch {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
 func (c *Client) ProcessRepos(ctx context.Context, progress *crawl.Progress, repos []*github.Repository, dryRun bool) ([]string, error) {
 	count := len(repos)
 	name := repos[0].GetName()
@@ -168,21 +212,43 @@ func (c *Client) ProcessRepos(ctx context.Context, progress *crawl.Progress, rep
 
 	urls := []string{}
 	for _, repo := range repos {
+		err := interruption(ctx)
+		if err != nil {
+			sort.Strings(urls)
+			return urls, err
+		}
+
 		name = repo.GetName()
 		owner = repo.GetOwner().GetLogin()
 		appendStr = fmt.Sprintf("\nCurrent Repo: %v/%v", owner, name)
 
 		url, err := c.processRepo(ctx, repo, dryRun)
 		if err != nil {
-			if errors.Is(err, ErrGetBranch) || errors.Is(err, ErrNoCommits) {
+			if errors.Is(err, ErrNoBranch) || errors.Is(err, ErrNoCommits) || errors.Is(err, ErrRepoDisabled) {
+				err = c.record(repo, ResultSkipped, "", err.Error())
+				if err != nil {
+					sort.Strings(urls)
+					return urls, err
+				}
+
 				repoBar.Incr()
 				continue
 			}
 
-			return nil, fmt.Errorf("process repo: %w", err)
+			c.record(repo, ResultFailed, "", err.Error()) //nolint: errcheck
+
+			sort.Strings(urls)
+			return urls, fmt.Errorf("process repo: %w", err)
 		}
 
 		urls = append(urls, url)
+
+		err = c.record(repo, ResultDone, url, "")
+		if err != nil {
+			sort.Strings(urls)
+			return urls, err
+		}
+
 		repoBar.Incr()
 	}
 
@@ -198,21 +264,49 @@ func (c *Client) processRepo(ctx context.Context, repo *github.Repository, dryRu
 	owner := repo.GetOwner().GetLogin()
 	head := repo.GetDefaultBranch()
 
-	c.rate.Wait(ctx) //nolint: errcheck
-	_, _, err := c.ghClient.Repositories.GetBranch(ctx, owner, name, c.cfg.ReleaseBranch)
+	settings, err := c.settingsFor(ctx, repo)
 	if err != nil {
-		return "", fmt.Errorf("%w: %w", ErrGetBranch, err)
+		return "", err
 	}
 
-	opts := &github.PullRequestListOptions{
-		Head: head,
-		Base: c.cfg.ReleaseBranch,
+	if !settings.enabled {
+		return "", ErrRepoDisabled
 	}
 
-	c.rate.Wait(ctx) //nolint: errcheck
-	prs, _, err := c.ghClient.PullRequests.List(ctx, owner, name, opts)
-	if err != nil {
-		return "", fmt.Errorf("list prs: %w", err)
+	base := settings.releaseBranch
+
+	var prs []*github.PullRequest
+	if state := c.stateFor(repo, base); state != nil {
+		if !state.branchExists {
+			return "", fmt.Errorf("%w: %s", ErrNoBranch, base)
+		}
+
+		if state.pr != nil {
+			prs = append(prs, state.pr)
+		} else if state.aheadBy == 0 {
+			return "", ErrNoCommits
+		}
+	} else {
+		c.rate.Wait(ctx) //nolint: errcheck
+		_, resp, err := c.ghClient.Repositories.GetBranch(ctx, owner, name, base)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return "", fmt.Errorf("%w: %s", ErrNoBranch, base)
+			}
+
+			return "", fmt.Errorf("get branch: %w", err)
+		}
+
+		opts := &github.PullRequestListOptions{
+			Head: head,
+			Base: base,
+		}
+
+		c.rate.Wait(ctx) //nolint: errcheck
+		prs, _, err = c.ghClient.PullRequests.List(ctx, owner, name, opts)
+		if err != nil {
+			return "", fmt.Errorf("list prs: %w", err)
+		}
 	}
 
 	if len(prs) > 0 {
@@ -220,20 +314,45 @@ func (c *Client) processRepo(ctx context.Context, repo *github.Repository, dryRu
 
 		pr.Title = github.String("Release")
 
-		changes, _ := c.createChangeLog(ctx, owner, name, c.cfg.ReleaseBranch, head)
+		changes, _, _ := c.createChangeLog(ctx, owner, name, base, head)
 		body := prBody(prBodyTemplate, changes)
 
 		pr.Body = github.String(body)
 
 		if !dryRun {
+			number := pr.GetNumber()
+
 			c.rate.Wait(ctx) //nolint: errcheck
-			pr, _, _ = c.ghClient.PullRequests.Edit(ctx, owner, name, pr.GetNumber(), pr)
+			edited, _, editErr := c.ghClient.PullRequests.Edit(ctx, owner, name, number, pr)
+
+			audited := edited
+			if audited == nil {
+				audited = pr
+			}
+
+			err = c.audit(ctx, AuditEvent{
+				Repo:      fmt.Sprintf("%v/%v", owner, name),
+				Action:    ChangeEdit,
+				Number:    number,
+				BeforeSHA: audited.GetBase().GetSHA(),
+				AfterSHA:  audited.GetBase().GetSHA(),
+				HeadSHA:   audited.GetHead().GetSHA(),
+			}, editErr)
+			if err != nil {
+				return "", err
+			}
+
+			if editErr != nil {
+				return "", fmt.Errorf("edit pr: %w", editErr)
+			}
+
+			pr = edited
 		}
 
 		return pr.GetHTMLURL(), nil
 	}
 
-	changes, err := c.createChangeLog(ctx, owner, name, c.cfg.ReleaseBranch, head)
+	changes, _, err := c.createChangeLog(ctx, owner, name, base, head)
 	if err != nil {
 		return "", err
 	}
@@ -243,28 +362,41 @@ func (c *Client) processRepo(ctx context.Context, repo *github.Repository, dryRu
 	newPR := &github.NewPullRequest{
 		Title:               github.String("Release"),
 		Head:                &head,
-		Base:                &c.cfg.ReleaseBranch,
+		Base:                &base,
 		Body:                github.String(body),
 		MaintainerCanModify: github.Bool(true),
 	}
 
 	if !dryRun {
 		c.rate.Wait(ctx) //nolint: errcheck
-		pr, _, err := c.ghClient.PullRequests.Create(ctx, owner, name, newPR)
+		pr, _, createErr := c.ghClient.PullRequests.Create(ctx, owner, name, newPR)
+
+		err = c.audit(ctx, AuditEvent{
+			Repo:      fmt.Sprintf("%v/%v", owner, name),
+			Action:    ChangeCreate,
+			Number:    pr.GetNumber(),
+			BeforeSHA: pr.GetBase().GetSHA(),
+			AfterSHA:  pr.GetBase().GetSHA(),
+			HeadSHA:   pr.GetHead().GetSHA(),
+		}, createErr)
 		if err != nil {
-			return "", fmt.Errorf("create pr: %w", err)
+			return "", err
+		}
+
+		if createErr != nil {
+			return "", fmt.Errorf("create pr: %w", createErr)
 		}
 
 		return pr.GetHTMLURL(), nil
 	}
 
-	return fmt.Sprintf("https://github.com/%s/%s/compare/%s...%s", owner, name, c.cfg.ReleaseBranch, head), nil
+	return fmt.Sprintf("%s/compare/%s...%s", repo.GetHTMLURL(), base, head), nil
 }
 
 func (c *Client) ReleaseRepos(ctx context.Context, progress *crawl.Progress, repos []*github.Repository, dryRun bool) ([]string, error) {
 	releases, err := c.getReleases(ctx, progress, repos)
 	if err != nil {
-		return nil, fmt.Errorf("releases: %v\n", err.Error())
+		return nil, fmt.Errorf("releases: %w", err)
 	}
 
 	if len(releases) < 1 {
@@ -293,32 +425,72 @@ func (c *Client) ReleaseRepos(ctx context.Context, progress *crawl.Progress, rep
 
 	var released []string
 	for _, release := range releases {
+		err := interruption(ctx)
+		if err != nil {
+			sort.Strings(released)
+			return released, err
+		}
+
 		repo := release.GetBase().GetRepo()
 		name = repo.GetName()
 		owner = repo.GetOwner().GetLogin()
 		appendStr = fmt.Sprintf("\nCurrent Repo: %v/%v", owner, name)
 
-		var err error
 		c.rate.Wait(ctx) //nolint: errcheck
 		release, _, err = c.ghClient.PullRequests.Get(ctx, owner, name, release.GetNumber())
 		if err != nil {
-			return nil, fmt.Errorf("check mergeable: %v", err.Error())
+			sort.Strings(released)
+			return released, fmt.Errorf("check mergeable: %w", err)
 		}
 
 		if strings.ToLower(release.GetMergeableState()) != "clean" {
+			err = c.record(repo, ResultSkipped, release.GetHTMLURL(), "not mergeable")
+			if err != nil {
+				sort.Strings(released)
+				return released, err
+			}
+
 			repoBar.Incr()
 			continue
 		}
 
+		settings, err := c.settingsFor(ctx, repo)
+		if err != nil {
+			sort.Strings(released)
+			return released, fmt.Errorf("settings: %w", err)
+		}
+
 		if !dryRun {
+			opts := &github.PullRequestOptions{
+				MergeMethod: settings.mergeMethod,
+			}
+
 			c.rate.Wait(ctx) //nolint: errcheck
-			res, _, err := c.ghClient.PullRequests.Merge(ctx, owner, name, release.GetNumber(), "release automerged by train", nil)
+			res, _, mergeErr := c.ghClient.PullRequests.Merge(ctx, owner, name, release.GetNumber(), "release automerged by train", opts)
+
+			err = c.audit(ctx, mergeEvent(repo, release, res), mergeError(res, mergeErr))
 			if err != nil {
-				return nil, fmt.Errorf("merge: %v", err.Error())
+				sort.Strings(released)
+				return released, err
+			}
+
+			if mergeErr != nil {
+				c.record(repo, ResultFailed, release.GetHTMLURL(), mergeErr.Error()) //nolint: errcheck
+
+				sort.Strings(released)
+				return released, fmt.Errorf("merge: %w", mergeErr)
 			}
 
 			if res.GetMerged() {
 				released = append(released, release.GetHTMLURL())
+				err = c.record(repo, ResultDone, release.GetHTMLURL(), "")
+			} else {
+				err = c.record(repo, ResultSkipped, release.GetHTMLURL(), res.GetMessage())
+			}
+
+			if err != nil {
+				sort.Strings(released)
+				return released, err
 			}
 		} else {
 			released = append(released, release.GetHTMLURL())
@@ -334,6 +506,34 @@ func (c *Client) ReleaseRepos(ctx context.Context, progress *crawl.Progress, rep
 	return released, nil
 }
 
+// mergeEvent returns the audit event for merging a release PR, which moves its
+// base branch on to the merge commit.
+func mergeEvent(repo *github.Repository, pr *github.PullRequest, res *github.PullRequestMergeResult) AuditEvent {
+	e := AuditEvent{
+		Repo:      fmt.Sprintf("%v/%v", repo.GetOwner().GetLogin(), repo.GetName()),
+		Action:    ChangeMerge,
+		Number:    pr.GetNumber(),
+		BeforeSHA: pr.GetBase().GetSHA(),
+		AfterSHA:  pr.GetBase().GetSHA(),
+		HeadSHA:   pr.GetHead().GetSHA(),
+	}
+
+	if res.GetMerged() {
+		e.AfterSHA = res.GetSHA()
+	}
+
+	return e
+}
+
+// mergeError returns why a merge did not happen, if it did not.
+func mergeError(res *github.PullRequestMergeResult, err error) error {
+	if err != nil || res.GetMerged() {
+		return err
+	}
+
+	return fmt.Errorf("not merged: %s", res.GetMessage())
+}
+
 func (c *Client) getReleases(ctx context.Context, progress *crawl.Progress, repos []*github.Repository) ([]*github.PullRequest, error) {
 	var releases []*github.PullRequest
 
@@ -357,14 +557,38 @@ func (c *Client) getReleases(ctx context.Context, progress *crawl.Progress, repo
 	progress.AddBar(repoBar)
 
 	for _, repo := range repos {
+		err := interruption(ctx)
+		if err != nil {
+			return nil, err
+		}
+
 		owner = repo.GetOwner().GetLogin()
 		name = repo.GetName()
 		appendStr = fmt.Sprintf("\nCurrent Repo: %v/%v", owner, name)
 		head := repo.GetDefaultBranch()
 
+		settings, err := c.settingsFor(ctx, repo)
+		if err != nil {
+			return nil, fmt.Errorf("settings: %v", err.Error())
+		}
+
+		if !settings.enabled {
+			repoBar.Incr()
+			continue
+		}
+
+		if state := c.stateFor(repo, settings.releaseBranch); state != nil {
+			if state.pr != nil {
+				releases = append(releases, state.pr)
+			}
+
+			repoBar.Incr()
+			continue
+		}
+
 		opts := &github.PullRequestListOptions{
 			Head: head,
-			Base: c.cfg.ReleaseBranch,
+			Base: settings.releaseBranch,
 		}
 
 		c.rate.Wait(ctx) //nolint: errcheck
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+// serveBranchStatus answers every request for the release branch of a repo
+// of gomicro with the status given.
+func serveBranchStatus(mux *http.ServeMux, name string, status int) {
+	mux.HandleFunc(fmt.Sprintf("/repos/gomicro/%s/branches/release", name), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"message":"nope"}`)
+	})
+}
+
+func TestProcessRepos(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	repo := func(name string) *github.Repository {
+		return &github.Repository{
+			Name:          github.String(name),
+			Owner:         &github.User{Login: github.String("gomicro")},
+			DefaultBranch: github.String("master"),
+		}
+	}
+
+	g.Describe("ProcessRepos", func() {
+		var (
+			mux *http.ServeMux
+			rec *results
+		)
+
+		g.BeforeEach(func() {
+			rec = &results{}
+			mux = http.NewServeMux()
+			serveFile(mux, "/repos/gomicro/.github/contents/train.yml", "")
+		})
+
+		g.It("should skip a repo without the release branch", func() {
+			serveBranchStatus(mux, "penname", http.StatusNotFound)
+			mux.HandleFunc("/", http.NotFound)
+
+			c := newTestClient(t, mux)
+			c.SetRecorder(rec)
+
+			urls, err := c.ProcessRepos(context.Background(), testProgress(), []*github.Repository{repo("penname")}, false)
+			Expect(err).To(BeNil())
+			Expect(urls).To(BeEmpty())
+			Expect(*rec).To(Equal(results{{Repo: "gomicro/penname", Status: ResultSkipped, Reason: "branch not found: release"}}))
+		})
+
+		g.It("should fail a repo whose release branch cannot be read", func() {
+			serveBranchStatus(mux, "penname", http.StatusBadGateway)
+			mux.HandleFunc("/", http.NotFound)
+
+			c := newTestClient(t, mux)
+			c.SetRecorder(rec)
+
+			_, err := c.ProcessRepos(context.Background(), testProgress(), []*github.Repository{repo("penname")}, false)
+			Expect(err).NotTo(BeNil())
+			Expect(errors.Is(err, ErrNoBranch)).To(BeFalse())
+			Expect(*rec).To(HaveLen(1))
+			Expect((*rec)[0].Status).To(Equal(ResultFailed))
+		})
+	})
+}
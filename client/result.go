@@ -0,0 +1,49 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	ResultDone    = "done"
+	ResultSkipped = "skipped"
+	ResultFailed  = "failed"
+)
+
+// Result represents the outcome of acting on a single repo.
+type Result struct {
+	Repo   string `json:"repo"`
+	Status string `json:"status"`
+	URL    string `json:"url,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Recorder receives the result for each repo as it is acted upon.
+type Recorder interface {
+	Record(Result) error
+}
+
+// SetRecorder sets where the result for each repo is sent as it completes.
+func (c *Client) SetRecorder(r Recorder) {
+	c.recorder = r
+}
+
+func (c *Client) record(repo *github.Repository, status, url, reason string) error {
+	if c.recorder == nil {
+		return nil
+	}
+
+	err := c.recorder.Record(Result{
+		Repo:   fmt.Sprintf("%v/%v", repo.GetOwner().GetLogin(), repo.GetName()),
+		Status: status,
+		URL:    url,
+		Reason: reason,
+	})
+	if err != nil {
+		return fmt.Errorf("record result: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+)
+
+// editPRPath matches the path of a pull request, which is edited with
+// absolute values and so is safe to send again.
+var editPRPath = regexp.MustCompile(`/repos/[^/]+/[^/]+/pulls/[0-9]+$`)
+
+// retryTransport retries idempotent requests that fail with a connection
+// error or a server error, backing off exponentially with jitter between
+// attempts.
+type retryTransport struct {
+	base    http.RoundTripper
+	retries int
+	log     io.Writer
+
+	delay func(attempt int) time.Duration
+	sleep func(context.Context, time.Duration) error
+}
+
+func newRetryTransport(base http.RoundTripper, retries int, log io.Writer) *retryTransport {
+	return &retryTransport{
+		base:    base,
+		retries: retries,
+		log:     log,
+
+		delay: backoff,
+		sleep: sleepContext,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !idempotent(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if !transient(req.Context(), resp, err) || attempt > t.retries {
+			return resp, err
+		}
+
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			resp.Body.Close()
+		}
+
+		wait := t.delay(attempt)
+
+		fmt.Fprintf(t.log, "retrying %s %s: %s (attempt %d of %d, waiting %v)\n", req.Method, req.URL.Path, reason, attempt, t.retries, wait.Round(time.Millisecond))
+
+		err = t.sleep(req.Context(), wait)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err = rewind(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// idempotent reports whether a request may safely be sent more than once.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPatch:
+		return (req.Body == nil || req.GetBody != nil) && editPRPath.MatchString(req.URL.Path)
+	case http.MethodPost:
+		// train only sends queries to graphql, never mutations
+		return req.GetBody != nil && strings.HasSuffix(req.URL.Path, "/graphql")
+	default:
+		return false
+	}
+}
+
+// transient reports whether a failed request is likely to succeed if sent
+// again.
+func transient(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before a retry, doubling with each attempt up to
+// a maximum, with a random jitter of up to half the delay.
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << uint(attempt-1)
+	if d <= 0 || d > retryMaxDelay {
+		d = retryMaxDelay
+	}
+
+	half := d / 2
+
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestRetryTransport(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Retry Transport", func() {
+		var slept []time.Duration
+		var log *bytes.Buffer
+		var bodies []string
+
+		newTransport := func(retries int, results ...interface{}) (*retryTransport, *int) {
+			calls := 0
+			base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if req.Body != nil {
+					var b bytes.Buffer
+					_, err := b.ReadFrom(req.Body)
+					Expect(err).To(BeNil())
+					bodies = append(bodies, b.String())
+				}
+
+				r := results[calls]
+				calls++
+
+				if err, ok := r.(error); ok {
+					return nil, err
+				}
+
+				return respond(r.(int), nil, `{}`), nil
+			})
+
+			rt := newRetryTransport(base, retries, log)
+			rt.delay = func(attempt int) time.Duration {
+				return time.Duration(attempt) * time.Second
+			}
+			rt.sleep = func(_ context.Context, d time.Duration) error {
+				slept = append(slept, d)
+				return nil
+			}
+
+			return rt, &calls
+		}
+
+		request := func(method, path, body string) *http.Request {
+			var req *http.Request
+			var err error
+			if body == "" {
+				req, err = http.NewRequest(method, "https://api.github.com"+path, nil)
+			} else {
+				req, err = http.NewRequest(method, "https://api.github.com"+path, strings.NewReader(body))
+			}
+			Expect(err).To(BeNil())
+
+			return req
+		}
+
+		g.BeforeEach(func() {
+			slept = nil
+			log = &bytes.Buffer{}
+			bodies = nil
+		})
+
+		g.It("should retry a read that fails with a server error", func() {
+			rt, calls := newTransport(3, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK)
+
+			resp, err := rt.RoundTrip(request(http.MethodGet, "/repos/gomicro/train", ""))
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(*calls).To(Equal(3))
+			Expect(slept).To(Equal([]time.Duration{time.Second, 2 * time.Second}))
+			Expect(log.String()).To(ContainSubstring("retrying GET /repos/gomicro/train: 502 Bad Gateway (attempt 1 of 3, waiting 1s)"))
+		})
+
+		g.It("should retry a read that fails to connect", func() {
+			rt, calls := newTransport(3, errors.New("connection reset by peer"), http.StatusOK)
+
+			resp, err := rt.RoundTrip(request(http.MethodGet, "/repos/gomicro/train", ""))
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(*calls).To(Equal(2))
+		})
+
+		g.It("should hand back the last failure once out of retries", func() {
+			rt, calls := newTransport(2, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+
+			resp, err := rt.RoundTrip(request(http.MethodGet, "/repos/gomicro/train", ""))
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(*calls).To(Equal(3))
+			Expect(slept).To(HaveLen(2))
+		})
+
+		g.It("should not retry a client error", func() {
+			rt, calls := newTransport(3, http.StatusNotFound)
+
+			resp, err := rt.RoundTrip(request(http.MethodGet, "/repos/gomicro/train", ""))
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(*calls).To(Equal(1))
+		})
+
+		g.It("should not retry a request that is not idempotent", func() {
+			rt, calls := newTransport(3, http.StatusBadGateway)
+
+			resp, err := rt.RoundTrip(request(http.MethodPost, "/repos/gomicro/train/pulls", `{"title":"Release"}`))
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(*calls).To(Equal(1))
+		})
+
+		g.It("should resend the body of a retried pull request edit", func() {
+			rt, calls := newTransport(3, http.StatusBadGateway, http.StatusOK)
+
+			resp, err := rt.RoundTrip(request(http.MethodPatch, "/repos/gomicro/train/pulls/7", `{"body":"changes"}`))
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(*calls).To(Equal(2))
+			Expect(bodies).To(Equal([]string{`{"body":"changes"}`, `{"body":"changes"}`}))
+		})
+
+		g.It("should not retry once the request is cancelled", func() {
+			rt, calls := newTransport(3, context.Canceled)
+
+			_, err := rt.RoundTrip(request(http.MethodGet, "/repos/gomicro/train", ""))
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(*calls).To(Equal(1))
+		})
+	})
+
+	g.Describe("idempotent", func() {
+		g.It("should only allow requests safe to send twice", func() {
+			get, _ := http.NewRequest(http.MethodGet, "https://api.github.com/repos/gomicro/train", nil)
+			Expect(idempotent(get)).To(BeTrue())
+
+			edit, _ := http.NewRequest(http.MethodPatch, "https://api.github.com/repos/gomicro/train/pulls/7", strings.NewReader(`{}`))
+			Expect(idempotent(edit)).To(BeTrue())
+
+			editRepo, _ := http.NewRequest(http.MethodPatch, "https://api.github.com/repos/gomicro/train", strings.NewReader(`{}`))
+			Expect(idempotent(editRepo)).To(BeFalse())
+
+			query, _ := http.NewRequest(http.MethodPost, "https://api.github.com/graphql", strings.NewReader(`{}`))
+			Expect(idempotent(query)).To(BeTrue())
+
+			merge, _ := http.NewRequest(http.MethodPut, "https://api.github.com/repos/gomicro/train/pulls/7/merge", strings.NewReader(`{}`))
+			Expect(idempotent(merge)).To(BeFalse())
+		})
+	})
+
+	g.Describe("backoff", func() {
+		g.It("should double with each attempt, with jitter, up to a maximum", func() {
+			for attempt, base := range map[int]time.Duration{1: retryBaseDelay, 2: 2 * retryBaseDelay, 3: 4 * retryBaseDelay, 20: retryMaxDelay, 100: retryMaxDelay} {
+				for i := 0; i < 20; i++ {
+					d := backoff(attempt)
+					Expect(d).To(BeNumerically(">=", base/2))
+					Expect(d).To(BeNumerically("<=", base))
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gomicro/crawl"
+	"github.com/gomicro/crawl/bar"
+	"github.com/google/go-github/github"
+)
+
+// maxSearchResults is the most results GitHub returns for a search query.
+const maxSearchResults = 1000
+
+var ErrSearchIncomplete = errors.New("search results incomplete")
+
+// SearchRepos returns the repos matching a GitHub repository search query,
+// filtered by the configured ignores. GitHub caps search results at 1000
+// repos per query, and may give up on a search before finding every match;
+// rather than act on some of the matching repos, either is returned as an
+// error.
+func (c *Client) SearchRepos(ctx context.Context, progress *crawl.Progress, query string) ([]*github.Repository, error) {
+	opts := &github.SearchOptions{
+		ListOptions: github.ListOptions{
+			Page:    0,
+			PerPage: 100,
+		},
+	}
+
+	var repoBar *bar.Bar
+	var repos []*github.Repository
+	for {
+		err := interruption(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		c.rate.Wait(ctx) //nolint: errcheck
+		res, resp, err := c.ghClient.Search.Repositories(ctx, query, opts)
+		if err != nil {
+			if _, ok := err.(*github.RateLimitError); ok {
+				return nil, fmt.Errorf("github: hit rate limit")
+			}
+
+			return nil, fmt.Errorf("search repos: %w", err)
+		}
+
+		if res.GetIncompleteResults() {
+			return nil, fmt.Errorf("search repos: %w: github timed out before finding every match, retry or narrow the query", ErrSearchIncomplete)
+		}
+
+		if repoBar == nil {
+			count := res.GetTotal()
+			if count < 1 {
+				return nil, fmt.Errorf("no repos found")
+			}
+
+			if count > maxSearchResults {
+				return nil, fmt.Errorf("search repos: %w: %d repos match, more than the %d github returns, narrow the query", ErrSearchIncomplete, count, maxSearchResults)
+			}
+
+			theme := bar.NewThemeFromTheme(bar.DefaultTheme)
+			theme.Append(func(b *bar.Bar) string {
+				return fmt.Sprintf(" %0.2f", b.CompletedPercent())
+			})
+			theme.Prepend(func(b *bar.Bar) string {
+				return fmt.Sprintf("Searching (%d/%d) %s", b.Current(), b.Total(), b.Elapsed())
+			})
+
+			repoBar = bar.New(theme, count)
+			progress.AddBar(repoBar)
+		}
+
+		for i := range res.Repositories {
+			repoBar.Incr()
+
+			repo := &res.Repositories[i]
+			skip, err := c.ignored(ctx, repo)
+			if err != nil {
+				return nil, fmt.Errorf("ignores: %w", err)
+			}
+
+			if skip {
+				continue
+			}
+
+			repos = append(repos, repo)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	if len(repos) < 1 {
+		return nil, fmt.Errorf("no repos found")
+	}
+
+	return repos, nil
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestSearch(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("SearchRepos", func() {
+		g.It("should page through the matching repos", func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("q")).To(Equal("org:gomicro topic:go"))
+
+				if r.URL.Query().Get("page") == "2" {
+					fmt.Fprint(w, `{"total_count":3,"items":[{"name":"penname","owner":{"login":"gomicro"}}]}`)
+					return
+				}
+
+				w.Header().Set("Link", fmt.Sprintf(`<%s?q=org%%3Agomicro+topic%%3Ago&page=2>; rel="next"`, r.URL.Path))
+				fmt.Fprint(w, `{"total_count":3,"items":[{"name":"steward","owner":{"login":"gomicro"}},{"name":"old","owner":{"login":"gomicro"},"archived":true}]}`)
+			})
+
+			c := newTestClient(t, mux)
+
+			repos, err := c.SearchRepos(context.Background(), testProgress(), "org:gomicro topic:go")
+			Expect(err).To(BeNil())
+			Expect(repos).To(HaveLen(2))
+			Expect(repos[0].GetName()).To(Equal("steward"))
+			Expect(repos[1].GetName()).To(Equal("penname"))
+		})
+
+		g.It("should refuse incomplete results", func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"total_count":1,"incomplete_results":true,"items":[{"name":"steward","owner":{"login":"gomicro"}}]}`)
+			})
+
+			c := newTestClient(t, mux)
+
+			_, err := c.SearchRepos(context.Background(), testProgress(), "org:gomicro")
+			Expect(errors.Is(err, ErrSearchIncomplete)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("timed out"))
+		})
+
+		g.It("should refuse more matches than github returns", func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"total_count":1001,"items":[{"name":"steward","owner":{"login":"gomicro"}}]}`)
+			})
+
+			c := newTestClient(t, mux)
+
+			_, err := c.SearchRepos(context.Background(), testProgress(), "org:gomicro")
+			Expect(errors.Is(err, ErrSearchIncomplete)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("1001 repos match"))
+		})
+
+		g.It("should stop when interrupted", func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+				g.Fail("searched after the run was interrupted")
+			})
+
+			c := newTestClient(t, mux)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := c.SearchRepos(ctx, testProgress(), "org:gomicro")
+			Expect(errors.Is(err, ErrInterrupted)).To(BeTrue())
+		})
+	})
+}
@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gomicro/train/config"
+	"github.com/google/go-github/github"
+	"gopkg.in/yaml.v2"
+)
+
+var ErrRepoDisabled = errors.New("repo disabled")
+
+// orgSettings represents the defaults for every repo within an org, after
+// applying any overrides from the org's config file.
+type orgSettings struct {
+	releaseBranch string
+	mergeMethod   string
+
+	ignoreRepoMap  map[string]struct{}
+	ignoreTopicMap map[string]struct{}
+}
+
+// repoSettings represents the effective settings for a single repo, after
+// applying any overrides from the org's and the repo's own config files.
+type repoSettings struct {
+	enabled       bool
+	releaseBranch string
+	mergeMethod   string
+}
+
+// settingsForOrg returns the effective defaults for an org. The org's config
+// file is only fetched once per run, and only overrides the settings not
+// explicitly set by a profile, a flag, or an environment variable.
+func (c *Client) settingsForOrg(ctx context.Context, org string) (*orgSettings, error) {
+	key := strings.ToLower(org)
+
+	if s, ok := c.orgSettings[key]; ok {
+		return s, nil
+	}
+
+	s := &orgSettings{
+		releaseBranch: c.cfg.ReleaseBranch,
+		mergeMethod:   c.cfg.MergeMethod,
+
+		ignoreRepoMap:  map[string]struct{}{},
+		ignoreTopicMap: map[string]struct{}{},
+	}
+
+	var oc config.OrgConfig
+	found, err := c.getConfigFile(ctx, org, config.OrgRepo, config.OrgFile, "", &oc)
+	if err != nil {
+		return nil, fmt.Errorf("get org config: %w", err)
+	}
+
+	if found {
+		err = oc.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s/%s: %w", org, config.OrgRepo, config.OrgFile, err)
+		}
+
+		if oc.ReleaseBranch != "" && !c.cfg.Explicit("release_branch") {
+			s.releaseBranch = oc.ReleaseBranch
+		}
+
+		if oc.MergeMethod != "" && !c.cfg.Explicit("merge_method") {
+			s.mergeMethod = oc.MergeMethod
+		}
+
+		if oc.Ignores != nil {
+			for i := range oc.Ignores.Repos {
+				s.ignoreRepoMap[strings.ToLower(oc.Ignores.Repos[i])] = struct{}{}
+			}
+
+			for i := range oc.Ignores.Topics {
+				s.ignoreTopicMap[strings.ToLower(oc.Ignores.Topics[i])] = struct{}{}
+			}
+		}
+	}
+
+	c.orgSettings[key] = s
+
+	return s, nil
+}
+
+// settingsFor returns the effective settings for a repo. The repo's config
+// file is only fetched once per run, and like the org's only overrides the
+// settings not explicitly set.
+func (c *Client) settingsFor(ctx context.Context, repo *github.Repository) (*repoSettings, error) {
+	owner := repo.GetOwner().GetLogin()
+	name := repo.GetName()
+	key := strings.ToLower(fmt.Sprintf("%v/%v", owner, name))
+
+	if s, ok := c.repoSettings[key]; ok {
+		return s, nil
+	}
+
+	org, err := c.settingsForOrg(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	var rc config.RepoConfig
+	_, err = c.getConfigFile(ctx, owner, name, config.RepoFile, repo.GetDefaultBranch(), &rc)
+	if err != nil {
+		return nil, fmt.Errorf("get repo config: %w", err)
+	}
+
+	err = rc.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s/%s: %w", owner, name, config.RepoFile, err)
+	}
+
+	s := &repoSettings{
+		enabled:       rc.IsEnabled(),
+		releaseBranch: org.releaseBranch,
+		mergeMethod:   org.mergeMethod,
+	}
+
+	if rc.ReleaseBranch != "" && !c.cfg.Explicit("release_branch") {
+		s.releaseBranch = rc.ReleaseBranch
+	}
+
+	if rc.MergeMethod != "" && !c.cfg.Explicit("merge_method") {
+		s.mergeMethod = rc.MergeMethod
+	}
+
+	c.repoSettings[key] = s
+
+	return s, nil
+}
+
+// getConfigFile fetches a yaml file from a repo at the ref given, or the
+// default branch if the ref is empty, and strictly unmarshals it into out. It returns
+// false if the repo or the file does not exist.
+func (c *Client) getConfigFile(ctx context.Context, owner, name, path, ref string, out interface{}) (bool, error) {
+	opts := &github.RepositoryContentGetOptions{
+		Ref: ref,
+	}
+
+	c.rate.Wait(ctx) //nolint: errcheck
+	file, _, resp, err := c.ghClient.Repositories.GetContents(ctx, owner, name, path, opts)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+
+		if _, ok := err.(*github.RateLimitError); ok {
+			return false, fmt.Errorf("github: hit rate limit")
+		}
+
+		return false, fmt.Errorf("get contents: %w", err)
+	}
+
+	if file == nil {
+		return false, nil
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return false, fmt.Errorf("decode %s/%s/%s: %w", owner, name, path, err)
+	}
+
+	// a mistyped key is refused rather than ignored, so a repo cannot think
+	// it has opted out when it has not
+	err = yaml.UnmarshalStrict([]byte(content), out)
+	if err != nil {
+		return false, fmt.Errorf("%s/%s/%s: %w: %v", owner, name, path, config.ErrInvalidConfig, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/train/config"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+func TestSettings(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("settingsFor", func() {
+		var mux *http.ServeMux
+
+		g.BeforeEach(func() {
+			mux = http.NewServeMux()
+			serveFile(mux, "/repos/gomicro/.github/contents/train.yml", "release_branch: staging\nmerge_method: rebase\n")
+			serveFile(mux, "/repos/gomicro/steward/contents/.train.yml", "release_branch: production\n")
+			mux.HandleFunc("/", http.NotFound)
+		})
+
+		repo := func(name string) *github.Repository {
+			return &github.Repository{
+				Name:          github.String(name),
+				Owner:         &github.User{Login: github.String("gomicro")},
+				DefaultBranch: github.String("main"),
+			}
+		}
+
+		g.It("should let org and repo files override the config files", func() {
+			c := newTestClient(t, mux)
+
+			s, err := c.settingsFor(context.Background(), repo("penname"))
+			Expect(err).To(BeNil())
+			Expect(s.releaseBranch).To(Equal("staging"))
+			Expect(s.mergeMethod).To(Equal("rebase"))
+
+			s, err = c.settingsFor(context.Background(), repo("steward"))
+			Expect(err).To(BeNil())
+			Expect(s.releaseBranch).To(Equal("production"))
+			Expect(s.mergeMethod).To(Equal("rebase"))
+		})
+
+		g.It("should not let org and repo files override a flag or environment variable", func() {
+			c := newTestClient(t, mux)
+
+			f, err := config.LookupField("release_branch")
+			Expect(err).To(BeNil())
+			Expect(f.Override(c.cfg, "hotfix")).To(Succeed())
+
+			s, err := c.settingsFor(context.Background(), repo("steward"))
+			Expect(err).To(BeNil())
+			Expect(s.releaseBranch).To(Equal("hotfix"))
+			Expect(s.mergeMethod).To(Equal("rebase"))
+		})
+
+		g.It("should not let org and repo files override a profile", func() {
+			c := newTestClient(t, mux)
+
+			c.cfg.Profiles = map[string]*config.Profile{
+				"work": {MergeMethod: "squash"},
+			}
+			Expect(c.cfg.ApplyProfile("work")).To(Succeed())
+
+			s, err := c.settingsFor(context.Background(), repo("steward"))
+			Expect(err).To(BeNil())
+			Expect(s.releaseBranch).To(Equal("production"))
+			Expect(s.mergeMethod).To(Equal("squash"))
+		})
+
+		g.It("should refuse a repo config file it cannot use", func() {
+			serveFile(mux, "/repos/gomicro/penname/contents/.train.yml", "merge_method: fastforward\n")
+
+			c := newTestClient(t, mux)
+
+			_, err := c.settingsFor(context.Background(), repo("penname"))
+			Expect(err).To(MatchError(config.ErrInvalidConfig))
+			Expect(err).To(MatchError(ContainSubstring("gomicro/penname/.train.yml")))
+		})
+
+		g.It("should refuse an org config file with a mistyped key", func() {
+			mux := http.NewServeMux()
+			serveFile(mux, "/repos/gomicro/.github/contents/train.yml", "ignore:\n  repos:\n    - sandbox\n")
+			mux.HandleFunc("/", http.NotFound)
+
+			c := newTestClient(t, mux)
+
+			_, err := c.settingsFor(context.Background(), repo("penname"))
+			Expect(err).To(MatchError(config.ErrInvalidConfig))
+			Expect(err).To(MatchError(ContainSubstring("gomicro/.github/train.yml")))
+			Expect(err).To(MatchError(ContainSubstring("field ignore not found")))
+		})
+
+		g.It("should refuse a repo config file with a mistyped key", func() {
+			serveFile(mux, "/repos/gomicro/penname/contents/.train.yml", "enabeld: false\n")
+
+			c := newTestClient(t, mux)
+
+			_, err := c.settingsFor(context.Background(), repo("penname"))
+			Expect(err).To(MatchError(config.ErrInvalidConfig))
+			Expect(err).To(MatchError(ContainSubstring("gomicro/penname/.train.yml")))
+			Expect(err).To(MatchError(ContainSubstring("field enabeld not found")))
+		})
+	})
+}
+
+// serveFile serves a file through the contents api at the path given.
+func serveFile(mux *http.ServeMux, path, content string) {
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"type":"file","encoding":"base64","content":%q}`, base64.StdEncoding.EncodeToString([]byte(content)))
+	})
+}
@@ -0,0 +1,256 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gomicro/crawl"
+	"github.com/gomicro/crawl/bar"
+	"github.com/google/go-github/github"
+)
+
+var (
+	ErrTeamNotFound      = errors.New("team not found")
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+// permissionLevels orders the repo permissions a team can hold, from least to
+// most privileged.
+var permissionLevels = []string{
+	"pull",
+	"triage",
+	"push",
+	"maintain",
+	"admin",
+}
+
+// GetTeams returns the slugs of all the teams within an org.
+func (c *Client) GetTeams(ctx context.Context, org string) ([]string, error) {
+	teams, err := c.listTeams(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	slugs := make([]string, 0, len(teams))
+	for i := range teams {
+		slugs = append(slugs, strings.ToLower(teams[i].GetSlug()))
+	}
+
+	return slugs, nil
+}
+
+// GetTeamRepos returns the repos a team has access to, filtered by the
+// configured ignores. If a permission is provided only repos the team holds at
+// least that permission on are returned.
+func (c *Client) GetTeamRepos(ctx context.Context, progress *crawl.Progress, org, slug, permission string) ([]*github.Repository, error) {
+	minLevel := 0
+	if permission != "" {
+		minLevel = permissionLevel(permission)
+		if minLevel < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, permission)
+		}
+	}
+
+	slug = strings.ToLower(slug)
+
+	team, err := c.getTeam(ctx, org, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	count := team.GetReposCount()
+	if count < 1 {
+		// the count is only used to size the progress bar, the repos listing
+		// is what decides whether there are any
+		count = 1
+	}
+
+	theme := bar.NewThemeFromTheme(bar.DefaultTheme)
+	theme.Append(func(b *bar.Bar) string {
+		return fmt.Sprintf(" %0.2f", b.CompletedPercent())
+	})
+	theme.Prepend(func(b *bar.Bar) string {
+		return fmt.Sprintf("Fetching (%d/%d) %s", b.Current(), b.Total(), b.Elapsed())
+	})
+
+	repoBar := bar.New(theme, count)
+	progress.AddBar(repoBar)
+
+	opts := &github.ListOptions{
+		Page:    0,
+		PerPage: 100,
+	}
+
+	var repos []*github.Repository
+	for {
+		err := interruption(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		rs, resp, err := c.listTeamRepos(ctx, org, slug, opts)
+		if err != nil {
+			if _, ok := err.(*github.RateLimitError); ok {
+				return nil, fmt.Errorf("github: hit rate limit")
+			}
+
+			return nil, fmt.Errorf("list team repos: %w", err)
+		}
+
+		for i := range rs {
+			repoBar.Incr()
+
+			skip, err := c.ignored(ctx, rs[i])
+			if err != nil {
+				return nil, fmt.Errorf("ignores: %w", err)
+			}
+
+			if skip {
+				continue
+			}
+
+			if minLevel > 0 && repoPermissionLevel(rs[i]) < minLevel {
+				continue
+			}
+
+			repos = append(repos, rs[i])
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	if len(repos) < 1 {
+		return nil, fmt.Errorf("no repos found")
+	}
+
+	return repos, nil
+}
+
+// getTeam returns the team of an org with the slug given.
+func (c *Client) getTeam(ctx context.Context, org, slug string) (*github.Team, error) {
+	req, err := c.ghClient.NewRequest(http.MethodGet, fmt.Sprintf("orgs/%v/teams/%v", org, url.PathEscape(slug)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("get team: %w", err)
+	}
+
+	team := &github.Team{}
+
+	c.rate.Wait(ctx) //nolint: errcheck
+	resp, err := c.ghClient.Do(ctx, req, team)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s/%s", ErrTeamNotFound, org, slug)
+		}
+
+		if _, ok := err.(*github.RateLimitError); ok {
+			return nil, fmt.Errorf("github: hit rate limit")
+		}
+
+		return nil, fmt.Errorf("get team: %w", err)
+	}
+
+	return team, nil
+}
+
+// listTeamRepos returns a page of the repos a team of an org has access to,
+// addressing the team by its slug, which the client library only supports
+// addressing by its legacy id.
+func (c *Client) listTeamRepos(ctx context.Context, org, slug string, opts *github.ListOptions) ([]*github.Repository, *github.Response, error) {
+	q := url.Values{}
+	q.Set("per_page", strconv.Itoa(opts.PerPage))
+	if opts.Page > 0 {
+		q.Set("page", strconv.Itoa(opts.Page))
+	}
+
+	req, err := c.ghClient.NewRequest(http.MethodGet, fmt.Sprintf("orgs/%v/teams/%v/repos?%v", org, url.PathEscape(slug), q.Encode()), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var repos []*github.Repository
+
+	c.rate.Wait(ctx) //nolint: errcheck
+	resp, err := c.ghClient.Do(ctx, req, &repos)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return repos, resp, nil
+}
+
+func (c *Client) listTeams(ctx context.Context, org string) ([]*github.Team, error) {
+	opts := &github.ListOptions{
+		Page:    0,
+		PerPage: 100,
+	}
+
+	var teams []*github.Team
+	for {
+		err := interruption(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		c.rate.Wait(ctx) //nolint: errcheck
+		ts, resp, err := c.ghClient.Teams.ListTeams(ctx, org, opts)
+		if err != nil {
+			if _, ok := err.(*github.RateLimitError); ok {
+				return nil, fmt.Errorf("github: hit rate limit")
+			}
+
+			return nil, fmt.Errorf("list teams: %w", err)
+		}
+
+		teams = append(teams, ts...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return teams, nil
+}
+
+// permissionLevel returns the rank of a permission name, or -1 if the name is
+// not recognized.
+func permissionLevel(permission string) int {
+	for i, p := range permissionLevels {
+		if strings.EqualFold(p, permission) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// repoPermissionLevel returns the rank of the highest permission held on a
+// repo as reported by the team repos listing.
+func repoPermissionLevel(repo *github.Repository) int {
+	if repo.Permissions == nil {
+		return -1
+	}
+
+	level := -1
+	for p, granted := range *repo.Permissions {
+		if !granted {
+			continue
+		}
+
+		if l := permissionLevel(p); l > level {
+			level = l
+		}
+	}
+
+	return level
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestTeams(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("GetTeamRepos", func() {
+		g.It("should page through the team's repos", func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/orgs/gomicro/teams", func(w http.ResponseWriter, r *http.Request) {
+				g.Fail("listed every team to find one")
+			})
+			mux.HandleFunc("/orgs/gomicro/teams/core", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id":7,"slug":"core","repos_count":3}`)
+			})
+			mux.HandleFunc("/orgs/gomicro/teams/core/repos", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("per_page")).To(Equal("100"))
+
+				if r.URL.Query().Get("page") == "2" {
+					fmt.Fprint(w, `[{"name":"penname","owner":{"login":"gomicro"},"permissions":{"pull":true}}]`)
+					return
+				}
+
+				w.Header().Set("Link", fmt.Sprintf(`<%s?page=2>; rel="next"`, r.URL.Path))
+				fmt.Fprint(w, `[{"name":"steward","owner":{"login":"gomicro"},"permissions":{"push":true}},{"name":"old","owner":{"login":"gomicro"},"archived":true}]`)
+			})
+			mux.HandleFunc("/", http.NotFound)
+
+			c := newTestClient(t, mux)
+
+			repos, err := c.GetTeamRepos(context.Background(), testProgress(), "gomicro", "Core", "")
+			Expect(err).To(BeNil())
+			Expect(repos).To(HaveLen(2))
+			Expect(repos[0].GetName()).To(Equal("steward"))
+			Expect(repos[1].GetName()).To(Equal("penname"))
+
+			repos, err = c.GetTeamRepos(context.Background(), testProgress(), "gomicro", "core", "push")
+			Expect(err).To(BeNil())
+			Expect(repos).To(HaveLen(1))
+			Expect(repos[0].GetName()).To(Equal("steward"))
+		})
+
+		g.It("should return an error for an unknown team", func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/orgs/gomicro/teams/core", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id":7,"slug":"core"}`)
+			})
+			mux.HandleFunc("/", http.NotFound)
+
+			c := newTestClient(t, mux)
+
+			_, err := c.GetTeamRepos(context.Background(), testProgress(), "gomicro", "infra", "")
+			Expect(err).To(MatchError(ErrTeamNotFound))
+			Expect(err.Error()).To(ContainSubstring("gomicro/infra"))
+		})
+
+		g.It("should stop paging when interrupted", func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/orgs/gomicro/teams/core", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id":7,"slug":"core"}`)
+			})
+			mux.HandleFunc("/orgs/gomicro/teams/core/repos", func(w http.ResponseWriter, r *http.Request) {
+				g.Fail("listed repos after the run was interrupted")
+			})
+
+			c := newTestClient(t, mux)
+
+			stop := make(chan struct{})
+			close(stop)
+
+			_, err := c.GetTeamRepos(WithStop(context.Background(), stop), testProgress(), "gomicro", "core", "")
+			Expect(err).To(MatchError(ErrInterrupted))
+		})
+	})
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gomicro/train/plan"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(NewApplyCmd(os.Stdout, setupClient))
+}
+
+func NewApplyCmd(out io.Writer, setupFunc func(*cobra.Command, []string)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply <plan-file>",
+		Short: "Carry out the changes in a plan",
+		Long:  `Carry out exactly the changes recorded by train plan. Any repo that has moved on from the commits it was planned against is refused, and left for a new plan. An apply is not journaled, and cannot be resumed; write a new plan instead.`,
+		Args:  cobra.ExactArgs(1),
+		RunE:  applyRun(out, setupFunc),
+	}
+
+	addRunFlags(cmd)
+
+	cmd.SetOut(out)
+
+	return cmd
+}
+
+func applyRun(out io.Writer, setupFunc func(*cobra.Command, []string)) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		p, err := plan.Read(args[0])
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("apply: %w", err)
+		}
+
+		if !viper.IsSet("profile") {
+			viper.Set("profile", p.Profile)
+		}
+
+		var entity []string
+		if p.Entity != "" {
+			entity = []string{p.Entity}
+		}
+
+		setupFunc(cmd, entity)
+
+		fmt.Fprintf(out, "Plan: %s of %d repos, planned %s\n", p.Action, len(p.Changes), p.Created.Local().Format(time.RFC822))
+
+		if dryRun {
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, "(Dryrun) Planned Changes:")
+			for _, ch := range p.Changes {
+				fmt.Fprintln(out, ch)
+			}
+
+			return nil
+		}
+
+		ctx, cancel := runContext(os.Stderr)
+		defer cancel()
+
+		err = clt.CheckAuth(ctx)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("apply: %w", err)
+		}
+
+		fmt.Fprintln(out)
+
+		progress, stopProgress := startProgress(ctx, out)
+		defer stopProgress()
+
+		urls, err := clt.Apply(ctx, progress, p.Changes)
+
+		stopProgress()
+
+		if len(urls) > 0 {
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, "Changes Applied:")
+
+			for _, url := range urls {
+				fmt.Fprintln(out, url)
+			}
+		}
+
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("apply: %w", err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gomicro/train/audit"
+	"github.com/gomicro/train/client"
+	"github.com/gomicro/train/config"
+	"github.com/spf13/cobra"
+)
+
+var ErrAuditStdout = errors.New("audit log is written to stdout, pass --file to query a saved copy")
+
+var (
+	auditFile   string
+	auditFilter audit.Filter
+	auditSince  string
+	auditJSON   bool
+)
+
+func init() {
+	rootCmd.AddCommand(NewAuditCmd(os.Stdout))
+}
+
+func NewAuditCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Query the log of changes made on github",
+		Long:  `Query the audit log, which records each release PR train created, edited, or merged, who it was done as, and the commits involved.`,
+		Args:  cobra.NoArgs,
+		RunE:  auditRun(out),
+	}
+
+	cmd.Flags().StringVar(&auditFile, "file", "", "audit log to query (default the audit_log configured)")
+	cmd.Flags().StringVar(&auditFilter.Repo, "repo", "", "only show changes to a repo, by name or owner/name")
+	cmd.Flags().StringVar(&auditFilter.Actor, "actor", "", "only show changes made as a login")
+	cmd.Flags().StringVar(&auditFilter.Action, "action", "", "only show changes of a kind (create, edit, or merge)")
+	cmd.Flags().StringVar(&auditFilter.Result, "result", "", "only show changes with a result (done or failed)")
+	cmd.Flags().StringVar(&auditSince, "since", "", "only show changes since a duration ago, such as 72h, or a date, such as 2006-01-02")
+	cmd.Flags().BoolVar(&auditJSON, "json", false, "print the matching records as json lines")
+
+	cmd.SetOut(out)
+
+	return cmd
+}
+
+func auditRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		path := auditFile
+		if path == "" {
+			c, err := loadConfig("")
+			if err != nil {
+				return fmt.Errorf("audit: %w", err)
+			}
+
+			path, err = auditPath(c)
+			if err != nil {
+				return fmt.Errorf("audit: %w", err)
+			}
+		}
+
+		if path == audit.Stdout {
+			return fmt.Errorf("audit: %w", ErrAuditStdout)
+		}
+
+		filter := auditFilter
+		if auditSince != "" {
+			since, err := parseSince(auditSince, time.Now())
+			if err != nil {
+				return fmt.Errorf("audit: %w", err)
+			}
+
+			filter.Since = since
+		}
+
+		events, err := audit.Query(path, &filter)
+		if err != nil {
+			return fmt.Errorf("audit: %w", err)
+		}
+
+		if auditJSON {
+			enc := json.NewEncoder(out)
+			for _, e := range events {
+				err := enc.Encode(e)
+				if err != nil {
+					return fmt.Errorf("audit: %w", err)
+				}
+			}
+
+			return nil
+		}
+
+		if len(events) == 0 {
+			fmt.Fprintln(out, "No changes recorded")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tACTOR\tHOST\tREPO\tACTION\tPR\tBEFORE\tAFTER\tRESULT")
+
+		for _, e := range events {
+			result := e.Result
+			if e.Error != "" {
+				result = fmt.Sprintf("%s: %s", result, e.Error)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+				e.Time.Local().Format(time.RFC822),
+				e.Actor,
+				e.Host,
+				e.Repo,
+				e.Action,
+				e.Number,
+				client.ShortSHA(e.BeforeSHA),
+				client.ShortSHA(e.AfterSHA),
+				result,
+			)
+		}
+
+		return w.Flush()
+	}
+}
+
+// auditPath returns where the audit log is written for the config given.
+func auditPath(c *config.Config) (string, error) {
+	if c.AuditLog != "" {
+		return c.AuditLog, nil
+	}
+
+	return audit.Path()
+}
+
+// parseSince returns the time a duration before now, or the start of a date.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	d, err := time.ParseDuration(s)
+	if err == nil {
+		return now.Add(-d), nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("since: expected a duration or a date: %s", s)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/penname"
+	"github.com/gomicro/train/audit"
+	"github.com/gomicro/train/client"
+	. "github.com/onsi/gomega"
+)
+
+func TestAuditCmd(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Audit", func() {
+		var file string
+
+		g.BeforeEach(func() {
+			file = filepath.Join(t.TempDir(), "audit.jsonl")
+
+			log := audit.New(file, nil)
+
+			Expect(log.Audit(client.AuditEvent{
+				Time:      time.Now().Add(-48 * time.Hour),
+				Actor:     "octocat",
+				Host:      "api.github.com",
+				Repo:      "gomicro/steward",
+				Action:    client.ChangeCreate,
+				Number:    4,
+				BeforeSHA: "1111111111",
+				AfterSHA:  "1111111111",
+				Result:    client.ResultDone,
+			})).To(BeNil())
+
+			Expect(log.Audit(client.AuditEvent{
+				Time:      time.Now(),
+				Actor:     "octocat",
+				Host:      "api.github.com",
+				Repo:      "gomicro/steward",
+				Action:    client.ChangeMerge,
+				Number:    4,
+				BeforeSHA: "1111111111",
+				AfterSHA:  "2222222222",
+				Result:    client.ResultDone,
+			})).To(BeNil())
+
+			Expect(log.Audit(client.AuditEvent{
+				Time:   time.Now(),
+				Actor:  "hubot",
+				Host:   "api.github.com",
+				Repo:   "gomicro/penname",
+				Action: client.ChangeMerge,
+				Number: 9,
+				Result: client.ResultFailed,
+				Error:  "not mergeable",
+			})).To(BeNil())
+		})
+
+		g.It("should show who merged what", func() {
+			w := penname.New()
+
+			cmd := NewAuditCmd(w)
+			cmd.SetArgs([]string{"--file", file, "--repo", "steward", "--action", "merge"})
+			Expect(cmd.Execute()).To(BeNil())
+
+			cmdOut := string(w.Written())
+			Expect(cmdOut).To(ContainSubstring("octocat"))
+			Expect(cmdOut).To(ContainSubstring("gomicro/steward"))
+			Expect(cmdOut).To(ContainSubstring("1111111  2222222"))
+			Expect(cmdOut).NotTo(ContainSubstring("gomicro/penname"))
+		})
+
+		g.It("should only show changes since the time given", func() {
+			w := penname.New()
+
+			cmd := NewAuditCmd(w)
+			cmd.SetArgs([]string{"--file", file, "--since", "24h", "--json"})
+			Expect(cmd.Execute()).To(BeNil())
+
+			lines := strings.Split(strings.TrimSpace(string(w.Written())), "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(ContainSubstring(`"action":"merge"`))
+			Expect(lines[1]).To(ContainSubstring(`"error":"not mergeable"`))
+		})
+	})
+}
@@ -2,7 +2,12 @@ package cmd
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"crypto/tls"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -10,11 +15,14 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"time"
 
 	"github.com/gomicro/train/config"
+	"github.com/gomicro/train/credential"
 
 	"github.com/gomicro/trust"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
 )
 
@@ -23,13 +31,34 @@ func init() {
 }
 
 const (
-	state = "8be0d61c-eff3-4785-af45-da69eae4f226"
+	authURL              = "https://github.com/login/oauth/authorize"
+	defaultDeviceCodeURL = "https://github.com/login/device/code"
+	defaultTokenURL      = "https://github.com/login/oauth/access_token"
+
+	stateBytes    = 32
+	verifierBytes = 32
+)
+
+var (
+	ErrBadState        = errors.New("bad response from oauth server: state did not match")
+	ErrCallbackTimeout = errors.New("timed out waiting for the browser to call back")
+
+	// callbackTimeout is how long the browser flow waits for github to redirect
+	// back to train.
+	callbackTimeout = 5 * time.Minute
 )
 
 var (
 	reapprove    bool
 	clientID     string
 	clientSecret string
+
+	credStore  string
+	credHelper string
+
+	device        bool
+	deviceCodeURL string
+	tokenURL      string
 )
 
 func NewAuthCmd(out io.Writer, browserFunc func(string) error) *cobra.Command {
@@ -41,6 +70,17 @@ func NewAuthCmd(out io.Writer, browserFunc func(string) error) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&reapprove, "force", "f", false, "force train to reauth")
+	cmd.Flags().StringVar(&credStore, "store", "", "where to keep the token (keyring, file, helper), the keyring by default when available and the config file otherwise")
+	cmd.Flags().StringVar(&credHelper, "helper", "", "credential helper command to use with the helper store")
+	cmd.Flags().BoolVar(&device, "device", false, "authorize with a code entered on another device, for headless machines")
+	cmd.Flags().StringVar(&deviceCodeURL, "device-code-url", defaultDeviceCodeURL, "endpoint requesting device codes")
+	cmd.Flags().StringVar(&tokenURL, "token-url", defaultTokenURL, "endpoint exchanging codes for tokens")
+
+	cmd.Flags().MarkHidden("device-code-url") //nolint: errcheck
+	cmd.Flags().MarkHidden("token-url")       //nolint: errcheck
+	cmd.AddCommand(NewAuthStatusCmd(out))
+	cmd.AddCommand(NewAuthLogoutCmd(out))
+
 	cmd.SetOut(out)
 
 	return cmd
@@ -51,14 +91,6 @@ func authRun(browserFunc func(string) error) func(*cobra.Command, []string) erro
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		listener, err := net.Listen("tcp", "127.0.0.1:0")
-		if err != nil {
-			cmd.SilenceUsage = true
-			return fmt.Errorf("auth: %w", err)
-		}
-
-		port := listener.Addr().(*net.TCPAddr).Port
-
 		pool := trust.New()
 
 		certs, err := pool.CACerts()
@@ -73,48 +105,29 @@ func authRun(browserFunc func(string) error) func(*cobra.Command, []string) erro
 			},
 		}
 
-		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
-
-		conf := &oauth2.Config{
-			ClientID:     clientID,
-			ClientSecret: clientSecret,
-			Scopes:       []string{"repo"},
-			Endpoint: oauth2.Endpoint{
-				AuthURL:  "https://github.com/login/oauth/authorize",
-				TokenURL: "https://github.com/login/oauth/access_token",
-			},
-			RedirectURL: fmt.Sprintf("http://localhost:%v/auth", port),
-		}
-
-		token := make(chan string)
-
-		go startServer(ctx, listener, conf, token)
-
-		var opts []oauth2.AuthCodeOption
-		if reapprove {
-			opts = []oauth2.AuthCodeOption{oauth2.AccessTypeOffline, oauth2.ApprovalForce}
+		var tkn string
+		if device {
+			tkn, err = deviceFlow(ctx, cmd.OutOrStdout(), httpClient, deviceCodeURL, tokenURL)
 		} else {
-			opts = []oauth2.AuthCodeOption{oauth2.AccessTypeOffline}
+			tkn, err = browserFlow(ctx, httpClient, browserFunc)
 		}
 
-		url := conf.AuthCodeURL(state, opts...)
-
-		err = browserFunc(url)
 		if err != nil {
 			cmd.SilenceUsage = true
 			return fmt.Errorf("auth: %w", err)
 		}
 
-		tkn := <-token
-		close(token)
-
 		c, err := config.ParseFromFile()
 		if err != nil {
 			cmd.SilenceUsage = true
 			return fmt.Errorf("auth: %w", err)
 		}
 
-		c.Github.Token = tkn
+		err = saveToken(c, viper.GetString("profile"), tkn, cmd.ErrOrStderr())
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("auth: %w", err)
+		}
 
 		err = c.WriteFile()
 		if err != nil {
@@ -126,41 +139,186 @@ func authRun(browserFunc func(string) error) func(*cobra.Command, []string) erro
 	}
 }
 
-func startServer(ctx context.Context, listener net.Listener, conf *oauth2.Config, token chan string) {
-	http.HandleFunc("/auth", authHandler(ctx, conf, token))
+// browserFlow authorizes train by sending the user to github in a browser,
+// and receiving the result on a local callback server. The state and PKCE
+// verifier are generated per run, and the flow gives up if the browser does
+// not call back within the callback timeout.
+func browserFlow(ctx context.Context, httpClient *http.Client, browserFunc func(string) error) (string, error) {
+	state, err := randomString(stateBytes)
+	if err != nil {
+		return "", fmt.Errorf("state: %w", err)
+	}
 
-	srv := &http.Server{}
+	verifier, err := randomString(verifierBytes)
+	if err != nil {
+		return "", fmt.Errorf("code verifier: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	ctx, cancel := context.WithTimeout(ctx, callbackTimeout)
+	defer cancel()
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+
+	conf := &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       []string{"repo"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  authURL,
+			TokenURL: tokenURL,
+		},
+		RedirectURL: fmt.Sprintf("http://localhost:%v/auth", port),
+	}
+
+	results := make(chan authResult, 1)
+
+	srv := newAuthServer(ctx, conf, state, verifier, results)
+	defer srv.Close()
 
 	go func() {
-		<-ctx.Done()
-		err := srv.Shutdown(ctx)
-		if err != nil {
-			fmt.Printf("Error shutting down server: %s", err)
-			os.Exit(1)
+		err := srv.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			sendResult(results, authResult{err: fmt.Errorf("callback server: %w", err)})
 		}
 	}()
 
-	err := srv.Serve(listener)
+	opts := []oauth2.AuthCodeOption{
+		oauth2.AccessTypeOffline,
+		oauth2.SetAuthURLParam("code_challenge", codeChallenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	}
+
+	if reapprove {
+		opts = append(opts, oauth2.ApprovalForce)
+	}
+
+	err = browserFunc(conf.AuthCodeURL(state, opts...))
 	if err != nil {
-		fmt.Printf("Error: %s", err)
-		os.Exit(1)
+		return "", err
+	}
+
+	select {
+	case res := <-results:
+		return res.token, res.err
+	case <-ctx.Done():
+		return "", ErrCallbackTimeout
 	}
 }
 
-func authHandler(ctx context.Context, conf *oauth2.Config, token chan string) func(w http.ResponseWriter, req *http.Request) {
+// saveToken keeps the token in the selected credential store, recording only
+// a reference to it in the config, unless the file store is selected. With no
+// store selected, the store the host already uses is kept, or else the keyring
+// is used, falling back to the config file when no keyring is available.
+func saveToken(c *config.Config, profile, tkn string, errOut io.Writer) error {
+	h := c.HostFor(profile)
+
+	store, helper := credStore, credHelper
+	auto := store == ""
+	if auto {
+		switch {
+		case h.Credential != nil && h.Credential.Store != "":
+			store, helper = h.Credential.Store, h.Credential.Helper
+		case credential.KeyringAvailable():
+			store = credential.StoreKeyring
+		default:
+			store = credential.StoreFile
+		}
+	}
+
+	if store == credential.StoreFile {
+		h.Token = tkn
+		h.Credential = nil
+		return nil
+	}
+
+	key := "github.com"
+	if profile != "" {
+		key = fmt.Sprintf("github.com/%s", profile)
+	}
+
+	cred := &config.Credential{
+		Store:  store,
+		Key:    key,
+		Helper: helper,
+	}
+
+	s, err := credential.New(cred)
+	if err != nil {
+		return err
+	}
+
+	err = s.Store(key, tkn)
+	if err != nil {
+		if !auto || store != credential.StoreKeyring {
+			return err
+		}
+
+		fmt.Fprintf(errOut, "Keyring unavailable, keeping the token in the config file: %s\n", err)
+
+		h.Token = tkn
+		h.Credential = nil
+
+		return nil
+	}
+
+	h.Token = ""
+	h.Credential = cred
+
+	return nil
+}
+
+type authResult struct {
+	token string
+	err   error
+}
+
+// sendResult reports the outcome of the callback without blocking, keeping
+// only the first one if the callback is hit more than once.
+func sendResult(results chan authResult, res authResult) {
+	select {
+	case results <- res:
+	default:
+	}
+}
+
+func newAuthServer(ctx context.Context, conf *oauth2.Config, state, verifier string, results chan authResult) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth", authHandler(ctx, conf, state, verifier, results))
+
+	return &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+func authHandler(ctx context.Context, conf *oauth2.Config, state, verifier string, results chan authResult) func(w http.ResponseWriter, req *http.Request) {
 	return func(w http.ResponseWriter, req *http.Request) {
-		code := req.URL.Query().Get("code")
-		rstate := req.URL.Query().Get("state")
+		q := req.URL.Query()
+
+		if subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(state)) != 1 {
+			http.Error(w, "bad response from oauth server", http.StatusBadRequest)
+			sendResult(results, authResult{err: ErrBadState})
+			return
+		}
 
-		if rstate != state {
-			fmt.Println("bad response from oauth server")
-			os.Exit(1)
+		if e := q.Get("error"); e != "" {
+			http.Error(w, "authorization failed", http.StatusBadRequest)
+			sendResult(results, authResult{err: fmt.Errorf("authorize: %s: %s", e, q.Get("error_description"))})
+			return
 		}
 
-		tok, err := conf.Exchange(ctx, code)
+		tok, err := conf.Exchange(ctx, q.Get("code"), oauth2.SetAuthURLParam("code_verifier", verifier))
 		if err != nil {
-			fmt.Printf("errored exchanging token: %s", err)
-			os.Exit(1)
+			http.Error(w, "failed exchanging token", http.StatusBadGateway)
+			sendResult(results, authResult{err: fmt.Errorf("exchange token: %w", err)})
+			return
 		}
 
 		body := `<html>
@@ -171,8 +329,26 @@ func authHandler(ctx context.Context, conf *oauth2.Config, token chan string) fu
 
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(body)) //nolint
-		token <- tok.AccessToken
+		sendResult(results, authResult{token: tok.AccessToken})
+	}
+}
+
+// randomString returns n random bytes, url safe base64 encoded.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
 	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge derives the S256 PKCE challenge for a verifier.
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
 }
 
 func openBrowser(url string) error {
@@ -181,14 +357,22 @@ func openBrowser(url string) error {
 	switch runtime.GOOS {
 	case "linux":
 		err := exec.CommandContext(ctx, "xdg-open", url).Start()
-		return fmt.Errorf("open browser: linux: %w", err)
+		if err != nil {
+			return fmt.Errorf("open browser: linux: %w", err)
+		}
 	case "windows":
 		err := exec.CommandContext(ctx, "rundll32", "url.dll,FileProtocolHandler", url).Start()
-		return fmt.Errorf("open browser: windows: %w", err)
+		if err != nil {
+			return fmt.Errorf("open browser: windows: %w", err)
+		}
 	case "darwin":
 		err := exec.CommandContext(ctx, "open", url).Start()
-		return fmt.Errorf("open browser: darwin: %w", err)
+		if err != nil {
+			return fmt.Errorf("open browser: darwin: %w", err)
+		}
 	default:
 		return fmt.Errorf("open browser: unsupported platform")
 	}
+
+	return nil
 }
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gomicro/train/config"
+	"github.com/gomicro/train/credential"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewAuthLogoutCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Revoke and remove the stored token",
+		Args:  cobra.NoArgs,
+		RunE:  authLogoutRun(out),
+	}
+
+	cmd.SetOut(out)
+
+	return cmd
+}
+
+func authLogoutRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		profile := viper.GetString("profile")
+
+		if clientID != "" {
+			// a token that cannot be resolved or revoked is still removed, as
+			// that is most often why someone is logging out
+			err := revokeToken(context.Background())
+			if err != nil {
+				fmt.Fprintf(out, "Token not revoked: %s\n", err)
+			} else {
+				fmt.Fprintln(out, "Token revoked")
+			}
+		} else {
+			fmt.Fprintln(out, "Token not revoked: this build of train has no oauth client configured")
+		}
+
+		c, err := config.ParseFromFile()
+		if err != nil {
+			return fmt.Errorf("auth: logout: %w", err)
+		}
+
+		h := c.HostFor(profile)
+
+		if h.Credential != nil && h.Credential.Store != credential.StoreFile {
+			s, err := credential.New(h.Credential)
+			if err != nil {
+				return fmt.Errorf("auth: logout: %w", err)
+			}
+
+			err = s.Erase(h.Credential.Key)
+			if err != nil {
+				return fmt.Errorf("auth: logout: %w", err)
+			}
+		}
+
+		h.Token = ""
+		h.Credential = nil
+
+		err = c.WriteFile()
+		if err != nil {
+			return fmt.Errorf("auth: logout: %w", err)
+		}
+
+		fmt.Fprintln(out, "Token removed")
+
+		if viper.IsSet("token") {
+			fmt.Fprintln(out, "A token is still set in the environment or flags")
+		}
+
+		return nil
+	}
+}
+
+// revokeToken revokes the token of the effective config with github.
+func revokeToken(ctx context.Context) error {
+	c, err := loadConfig("")
+	if err != nil {
+		return err
+	}
+
+	err = credential.Resolve(c)
+	if err != nil {
+		return err
+	}
+
+	rc, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	return rc.RevokeToken(ctx, clientID, clientSecret)
+}
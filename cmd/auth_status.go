@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gomicro/train/config"
+	"github.com/gomicro/train/credential"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewAuthStatusCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Display the current auth status",
+		Long:  `Display the account train is logged in as, where its token comes from, and what the token may access`,
+		Args:  cobra.NoArgs,
+		RunE:  authStatusRun(out),
+	}
+
+	cmd.SetOut(out)
+
+	return cmd
+}
+
+func authStatusRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		c, err := loadConfig("")
+		if err != nil {
+			return fmt.Errorf("auth: status: %w", err)
+		}
+
+		err = credential.Resolve(c)
+		if err != nil {
+			fmt.Fprintln(out, "Logged in as: unknown")
+			fmt.Fprintf(out, "Token source: %s\n", tokenSource(c))
+			fmt.Fprintf(out, "Token could not be resolved: %s\n", err)
+			fmt.Fprintln(out, "Run train auth logout to clear it, then train auth to log in again")
+
+			return nil
+		}
+
+		sc, err := newClient(c)
+		if err != nil {
+			return fmt.Errorf("auth: status: %w", err)
+		}
+
+		status, err := sc.GetAuthStatus(context.Background())
+		if err != nil {
+			return fmt.Errorf("auth: status: %w", err)
+		}
+
+		fmt.Fprintf(out, "Logged in as: %s\n", status.Login)
+		fmt.Fprintf(out, "Token source: %s\n", tokenSource(c))
+
+		if status.Scopes != nil {
+			fmt.Fprintf(out, "Scopes: %s\n", strings.Join(status.Scopes, ", "))
+		} else {
+			fmt.Fprintln(out, "Scopes: not reported")
+		}
+
+		if status.Expiry != "" {
+			fmt.Fprintf(out, "Expires: %s\n", status.Expiry)
+		} else {
+			fmt.Fprintln(out, "Expires: never")
+		}
+
+		if len(status.Orgs) > 0 {
+			fmt.Fprintln(out, "Orgs:")
+		}
+
+		for _, o := range status.Orgs {
+			switch {
+			case o.Authorized:
+				fmt.Fprintf(out, "  %s: authorized\n", o.Org)
+			case o.SSOURL != "":
+				fmt.Fprintf(out, "  %s: SSO authorization required, authorize at %s\n", o.Org, o.SSOURL)
+			default:
+				fmt.Fprintf(out, "  %s: SSO authorization required\n", o.Org)
+			}
+		}
+
+		return nil
+	}
+}
+
+// tokenSource describes where the credentials in the config came from.
+func tokenSource(c *config.Config) string {
+	switch {
+	case c.Github.App.IsSet():
+		return "github app"
+	case viper.IsSet("token"):
+		return "environment or flag"
+	case c.Github.Credential != nil && c.Github.Credential.Store != credential.StoreFile:
+		return fmt.Sprintf("%s (%s)", c.Github.Credential.Store, c.Github.Credential.Key)
+	default:
+		return "config file"
+	}
+}
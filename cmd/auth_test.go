@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestBrowserFlow(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Browser Flow", func() {
+		var srv *httptest.Server
+		var verifier string
+
+		g.BeforeEach(func() {
+			verifier = ""
+
+			srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.ParseForm()).To(BeNil())
+				Expect(r.FormValue("code")).To(Equal("abc"))
+				verifier = r.FormValue("code_verifier")
+
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"access_token":"tkn","token_type":"bearer"}`)
+			}))
+
+			tokenURL = srv.URL
+		})
+
+		g.AfterEach(func() {
+			srv.Close()
+			tokenURL = defaultTokenURL
+		})
+
+		callback := func(state string) func(string) error {
+			return func(u string) error {
+				au, err := url.Parse(u)
+				if err != nil {
+					return err
+				}
+
+				q := au.Query()
+				Expect(q.Get("code_challenge_method")).To(Equal("S256"))
+				Expect(q.Get("code_challenge")).NotTo(BeEmpty())
+
+				if state == "" {
+					state = q.Get("state")
+				}
+
+				go func() {
+					resp, err := http.Get(fmt.Sprintf("%s?code=abc&state=%s", q.Get("redirect_uri"), state))
+					if err == nil {
+						resp.Body.Close()
+					}
+				}()
+
+				return nil
+			}
+		}
+
+		g.It("should exchange the code with the pkce verifier", func() {
+			var challenge string
+
+			tkn, err := browserFlow(context.Background(), srv.Client(), func(u string) error {
+				au, _ := url.Parse(u)
+				challenge = au.Query().Get("code_challenge")
+
+				return callback("")(u)
+			})
+			Expect(err).To(BeNil())
+			Expect(tkn).To(Equal("tkn"))
+			Expect(codeChallenge(verifier)).To(Equal(challenge))
+		})
+
+		g.It("should use a new state each run", func() {
+			var states []string
+
+			for i := 0; i < 2; i++ {
+				_, err := browserFlow(context.Background(), srv.Client(), func(u string) error {
+					au, _ := url.Parse(u)
+					states = append(states, au.Query().Get("state"))
+
+					return callback("")(u)
+				})
+				Expect(err).To(BeNil())
+			}
+
+			Expect(states[0]).NotTo(Equal(states[1]))
+		})
+
+		g.It("should return an error for a mismatched state", func() {
+			_, err := browserFlow(context.Background(), srv.Client(), callback("forged"))
+			Expect(err).To(MatchError(ErrBadState))
+		})
+
+		g.It("should time out when the browser never calls back", func() {
+			callbackTimeout = 50 * time.Millisecond
+			defer func() { callbackTimeout = 5 * time.Minute }()
+
+			_, err := browserFlow(context.Background(), srv.Client(), func(string) error { return nil })
+			Expect(err).To(MatchError(ErrCallbackTimeout))
+		})
+	})
+}
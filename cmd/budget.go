@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gomicro/train/client"
+	"github.com/google/go-github/github"
+	"github.com/spf13/cobra"
+)
+
+var ignoreBudget bool
+
+// addBudgetFlags registers the flags controlling how a command treats the api
+// rate limit budget.
+func addBudgetFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&ignoreBudget, "ignore-budget", false, "run even if the estimated api calls exceed the remaining rate limit, waiting for it to reset as needed")
+}
+
+// checkBudget estimates the api calls the action will make against the repos.
+// A live run plans its changes for confirming first unless told not to ask, so
+// dry runs estimate it that way too. A live run that would exhaust the rate
+// limit is refused, unless the budget is being ignored.
+func checkBudget(ctx context.Context, action string, repos []*github.Repository) (*client.CostEstimate, error) {
+	est, err := clt.EstimateCost(ctx, action, repos, !assumeYes)
+	if err != nil {
+		return nil, err
+	}
+
+	if est.Exceeds() && !dryRun && !ignoreBudget {
+		return nil, fmt.Errorf("%w: estimated %s, wait for the reset or rerun with --ignore-budget", client.ErrBudgetExceeded, est)
+	}
+
+	return est, nil
+}
+
+// printEstimate reports the estimated cost of a run, which is always shown
+// for dry runs so that live runs can be scheduled around the rate limit.
+func printEstimate(out io.Writer, est *client.CostEstimate) {
+	if !dryRun && !est.Exceeds() {
+		return
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Estimated cost: %s\n", est)
+
+	if !est.Exceeds() {
+		return
+	}
+
+	if dryRun {
+		fmt.Fprintln(out, "Warning: a live run would exceed the remaining rate limit and be refused, unless rerun with --ignore-budget")
+		return
+	}
+
+	fmt.Fprintln(out, "Warning: this run exceeds the remaining rate limit, and will wait for it to reset as needed")
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/train/client"
+	. "github.com/onsi/gomega"
+)
+
+func TestPrintEstimate(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("printEstimate", func() {
+		var est *client.CostEstimate
+
+		g.BeforeEach(func() {
+			est = &client.CostEstimate{Calls: 20, Remaining: 10, Limit: 5000, Reset: time.Now()}
+		})
+
+		g.AfterEach(func() {
+			dryRun = false
+		})
+
+		g.It("should warn a dry run that a live run would be refused", func() {
+			dryRun = true
+
+			buf := &bytes.Buffer{}
+			printEstimate(buf, est)
+			Expect(buf.String()).To(ContainSubstring("Estimated cost: 20 api calls, 10 of 5000 remaining"))
+			Expect(buf.String()).To(ContainSubstring("be refused, unless rerun with --ignore-budget"))
+		})
+
+		g.It("should warn a live run ignoring the budget that it will wait", func() {
+			buf := &bytes.Buffer{}
+			printEstimate(buf, est)
+			Expect(buf.String()).To(ContainSubstring("will wait for it to reset"))
+		})
+
+		g.It("should say nothing for a live run within the budget", func() {
+			est.Remaining = 30
+
+			buf := &bytes.Buffer{}
+			printEstimate(buf, est)
+			Expect(buf.String()).To(BeEmpty())
+		})
+	})
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gomicro/train/cache"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewCacheCmd(os.Stdout))
+}
+
+func NewCacheCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage cached github responses",
+		Long:  `Manage the github responses train caches, which are revalidated with conditional requests that do not count against the rate limit.`,
+	}
+
+	cmd.AddCommand(NewCacheClearCmd(out))
+
+	cmd.SetOut(out)
+
+	return cmd
+}
+
+func NewCacheClearCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached github responses",
+		Long:  `Remove all cached github responses, so the next run fetches everything fresh.`,
+		Args:  cobra.NoArgs,
+		RunE:  cacheClearRun(out),
+	}
+
+	cmd.SetOut(out)
+
+	return cmd
+}
+
+func cacheClearRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		dir, err := cache.Dir()
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("cache: clear: %w", err)
+		}
+
+		n, err := cache.New(dir, cache.DefaultMaxSize).Clear()
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("cache: clear: %w", err)
+		}
+
+		fmt.Fprintf(out, "Removed %d cached responses\n", n)
+
+		return nil
+	}
+}
@@ -1,56 +1,407 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
 	"strings"
 
 	"github.com/gomicro/train/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultEditor = "vi"
+)
+
+var (
+	ErrUnsetSecret = errors.New("secrets may not be unset")
+
+	migrateDryRun bool
 )
 
 func init() {
-	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(NewConfigCmd(os.Stdout))
+}
+
+func NewConfigCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "config [config_field] [value]",
+		Short:             "config train",
+		Long:              `configure train`,
+		Args:              cobra.ExactArgs(2),
+		RunE:              configSetRun(out),
+		ValidArgsFunction: configSetValidArgsFunc,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:               "get [config_field]",
+		Short:             "Display the value of a config field",
+		Args:              cobra.ExactArgs(1),
+		RunE:              configGetRun(out),
+		ValidArgsFunction: configFieldValidArgsFunc,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:               "set [config_field] [value]",
+		Short:             "Set the value of a config field, lists are comma separated",
+		Args:              cobra.ExactArgs(2),
+		RunE:              configSetRun(out),
+		ValidArgsFunction: configSetValidArgsFunc,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:               "unset [config_field]",
+		Short:             "Return a config field to its default value",
+		Args:              cobra.ExactArgs(1),
+		RunE:              configUnsetRun(out),
+		ValidArgsFunction: configFieldValidArgsFunc,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:               "add [config_field] [value...]",
+		Short:             "Add values to a list config field",
+		Args:              cobra.MinimumNArgs(2),
+		RunE:              configAddRun(out),
+		ValidArgsFunction: configListFieldValidArgsFunc,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:               "remove [config_field] [value...]",
+		Short:             "Remove values from a list config field",
+		Args:              cobra.MinimumNArgs(2),
+		RunE:              configRemoveRun(out),
+		ValidArgsFunction: configListFieldValidArgsFunc,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "Display the value of every config field",
+		Args:  cobra.NoArgs,
+		RunE:  configListRun(out),
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate [org_name|user_name]",
+		Short: "Check the config for errors",
+		Long:  `Check the effective config, including project files, profiles, and overrides, for errors`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  configValidateRun(out),
+	})
+
+	migrateCmd := &cobra.Command{
+		Use:   "migrate [file]",
+		Short: "Upgrade a config file to the current format",
+		Long:  `Upgrade a config file, the user's config file by default, to the current format, keeping a backup of the original`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  configMigrateRun(out),
+	}
+
+	migrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "preview the upgrade without writing any files")
+
+	cmd.AddCommand(migrateCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "edit",
+		Short: "Open the config file in an editor",
+		Long:  `Open the config file in the editor set by VISUAL or EDITOR`,
+		Args:  cobra.NoArgs,
+		RunE:  configEditRun,
+	})
+
+	cmd.SetOut(out)
+
+	return cmd
+}
+
+func configGetRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		f, err := config.LookupField(args[0])
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+
+		confFile, err := config.ParseFromFile()
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("config: %w", err)
+		}
+
+		fmt.Fprintln(out, f.Get(confFile))
+
+		return nil
+	}
+}
+
+func configSetRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return updateConfig(cmd, out, args[0], func(f *config.Field, c *config.Config) error {
+			if f.Secret {
+				// secrets go where train auth would keep them, rather
+				// than always into the config file
+				return saveToken(c, "", args[1], cmd.ErrOrStderr())
+			}
+
+			return f.Set(c, args[1])
+		})
+	}
+}
+
+func configUnsetRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return updateConfig(cmd, out, args[0], func(f *config.Field, c *config.Config) error {
+			if f.Secret {
+				return fmt.Errorf("%w: %s, use train auth logout to remove the token", ErrUnsetSecret, f.Path)
+			}
+
+			f.Unset(c)
+			return nil
+		})
+	}
 }
 
-var configValidArgs = []string{
-	"release_branch\tthe head branch name to use for creating the release PRs",
+func configAddRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return updateConfig(cmd, out, args[0], func(f *config.Field, c *config.Config) error {
+			return f.Add(c, args[1:]...)
+		})
+	}
 }
 
-var configCmd = &cobra.Command{
-	Use:       "config [config_field] [value]",
-	Short:     "config train",
-	Long:      `configure train`,
-	Args:      cobra.ExactArgs(2),
-	RunE:      configFunc,
-	ValidArgs: configValidArgs,
+func configRemoveRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return updateConfig(cmd, out, args[0], func(f *config.Field, c *config.Config) error {
+			return f.Remove(c, args[1:]...)
+		})
+	}
 }
 
-func configFunc(cmd *cobra.Command, args []string) error {
-	field := args[0]
-	value := args[1]
+func configListRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		confFile, err := config.ParseFromFile()
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("config: %w", err)
+		}
 
+		for _, f := range config.Fields {
+			v := f.Get(confFile)
+			if f.Secret && v != "" {
+				v = "<redacted>"
+			}
+
+			fmt.Fprintf(out, "%s=%s\n", f.Path, v)
+		}
+
+		return nil
+	}
+}
+
+func configValidateRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		entity := ""
+		if len(args) > 0 {
+			entity = args[0]
+		}
+
+		confFile, err := loadConfig(entity)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+
+		err = confFile.Validate()
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+
+		fmt.Fprintln(out, "Config is valid")
+
+		return nil
+	}
+}
+
+func configMigrateRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		var path string
+		if len(args) > 0 {
+			path = args[0]
+		} else {
+			exists, err := config.FileExists()
+			if err != nil {
+				return fmt.Errorf("config: %w", err)
+			}
+
+			if !exists {
+				fmt.Fprintln(out, "No config file to migrate")
+				return nil
+			}
+
+			path, err = config.FilePath()
+			if err != nil {
+				return fmt.Errorf("config: %w", err)
+			}
+		}
+
+		dry := migrateDryRun || viper.GetBool("dryRun")
+
+		report, err := config.MigrateFile(path, dry)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+
+		if dry && report.Changed() {
+			fmt.Fprint(out, "(Dryrun) ")
+		}
+
+		fmt.Fprintln(out, report)
+
+		return nil
+	}
+}
+
+func configEditRun(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	// parsing creates the config dir if it's missing
 	confFile, err := config.ParseFromFile()
 	if err != nil {
-		cmd.SilenceUsage = true
 		return fmt.Errorf("config: %w", err)
 	}
 
-	switch strings.ToLower(field) {
-	case "release_branch":
-		confFile.ReleaseBranch = value
-	default:
-		cmd.SilenceUsage = true
-		return fmt.Errorf("config: unreconized config field: %s", field)
+	exists, err := config.FileExists()
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	if !exists {
+		err = confFile.WriteFile()
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+	}
+
+	path, err := config.FilePath()
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if editor == "" {
+		editor = defaultEditor
+	}
+
+	parts := strings.Fields(editor)
+
+	e := exec.Command(parts[0], append(parts[1:], path)...) //nolint: gosec
+	e.Stdin = os.Stdin
+	e.Stdout = os.Stdout
+	e.Stderr = os.Stderr
+
+	err = e.Run()
+	if err != nil {
+		return fmt.Errorf("config: edit: %w", err)
+	}
+
+	confFile, err = config.ParseFromFile()
+	if err != nil {
+		return fmt.Errorf("config: edited file is invalid: %w", err)
+	}
+
+	err = confFile.Validate()
+	if err != nil {
+		return fmt.Errorf("config: edited file is invalid: %w", err)
+	}
+
+	return nil
+}
+
+// updateConfig applies an update to a single field of the user's config file
+// and writes the result back out, refusing any update leaving the config
+// invalid.
+func updateConfig(cmd *cobra.Command, out io.Writer, path string, update func(*config.Field, *config.Config) error) error {
+	f, err := config.LookupField(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	cmd.SilenceUsage = true
+
+	confFile, err := config.ParseFromFile()
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	err = update(f, confFile)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	err = confFile.Validate()
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
 	}
 
 	err = confFile.WriteFile()
 	if err != nil {
-		cmd.SilenceUsage = true
 		return fmt.Errorf("config: %w", err)
 	}
 
-	// TODO: change to verbose output
-	fmt.Println("Config file updated")
+	fmt.Fprintln(out, "Config file updated")
 
 	return nil
 }
+
+func configFieldValidArgsFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return fieldCompletions(func(*config.Field) bool { return true }), cobra.ShellCompDirectiveNoFileComp
+}
+
+func configListFieldValidArgsFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return fieldCompletions(func(f *config.Field) bool { return f.Kind == config.KindList }), cobra.ShellCompDirectiveNoFileComp
+}
+
+func configSetValidArgsFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return fieldCompletions(func(*config.Field) bool { return true }), cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		f, err := config.LookupField(args[0])
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return f.Values, cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func fieldCompletions(include func(*config.Field) bool) []string {
+	valid := []string{}
+
+	for _, f := range config.Fields {
+		if include(f) {
+			valid = append(valid, fmt.Sprintf("%s\t%s", f.Path, f.Usage))
+		}
+	}
+
+	return valid
+}
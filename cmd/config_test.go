@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/penname"
+	"github.com/gomicro/train/config"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfigCmd(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	sysPath := os.Getenv("PATH")
+
+	g.Describe("Config", func() {
+		g.It("should return an error for an unknown field", func() {
+			w := penname.New()
+			c := NewConfigCmd(w)
+
+			c.SetArgs([]string{"get", "github.com.limits.bursts"})
+			err := c.Execute()
+			Expect(err).To(MatchError(config.ErrUnknownField))
+		})
+
+		g.It("should complete field names", func() {
+			c := NewConfigCmd(penname.New())
+
+			valid, _ := configSetValidArgsFunc(c, []string{}, "")
+			Expect(valid).To(ContainElement(HavePrefix("github.com.limits.burst\t")))
+
+			valid, _ = configSetValidArgsFunc(c, []string{"merge_method"}, "")
+			Expect(valid).To(ConsistOf("merge", "squash", "rebase"))
+		})
+
+		g.Describe("Updating fields", func() {
+			var path string
+
+			run := func(args ...string) error {
+				c := NewConfigCmd(penname.New())
+				c.SetArgs(args)
+				return c.Execute()
+			}
+
+			read := func() *config.Config {
+				conf, err := config.ParseFromFile()
+				Expect(err).To(BeNil())
+				return conf
+			}
+
+			g.BeforeEach(func() {
+				home := t.TempDir()
+				t.Setenv("HOME", home)
+
+				path = filepath.Join(home, ".train", "config")
+				Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+				Expect(os.WriteFile(path, []byte("version: 1\nrelease_branch: release\ngithub.com:\n  limits:\n    request_per_second: 5\n    burst: 5\n    retries: 1\n"), 0600)).To(Succeed())
+			})
+
+			g.It("should set a field", func() {
+				Expect(run("set", "github.com.limits.burst", "40")).To(Succeed())
+				Expect(read().Github.Limits.Burst).To(Equal(40))
+
+				Expect(run("github.com.ignores.repos", "sandbox,scratch")).To(Succeed())
+				Expect(read().Github.Ignores.Repos).To(Equal([]string{"sandbox", "scratch"}))
+			})
+
+			g.It("should unset a field", func() {
+				Expect(run("unset", "github.com.limits.burst")).To(Succeed())
+				Expect(read().Github.Limits.Burst).To(Equal(config.Default().Github.Limits.Burst))
+			})
+
+			g.It("should add to and remove from a list field", func() {
+				Expect(run("add", "github.com.ignores.topics", "archived", "sandbox")).To(Succeed())
+				Expect(run("add", "github.com.ignores.topics", "sandbox", "legacy")).To(Succeed())
+				Expect(read().Github.Ignores.Topics).To(Equal([]string{"archived", "sandbox", "legacy"}))
+
+				Expect(run("remove", "github.com.ignores.topics", "sandbox", "missing")).To(Succeed())
+				Expect(read().Github.Ignores.Topics).To(Equal([]string{"archived", "legacy"}))
+			})
+
+			g.It("should refuse an update leaving the config invalid", func() {
+				before, err := os.ReadFile(path)
+				Expect(err).To(BeNil())
+
+				err = run("set", "github.com.limits.burst", "0")
+				Expect(errors.Is(err, config.ErrInvalidConfig)).To(BeTrue())
+
+				err = run("set", "release_branch", "")
+				Expect(errors.Is(err, config.ErrInvalidConfig)).To(BeTrue())
+
+				after, err := os.ReadFile(path)
+				Expect(err).To(BeNil())
+				Expect(after).To(Equal(before))
+			})
+
+			g.It("should keep a token set in the config file when no keyring is available", func() {
+				t.Setenv("PATH", t.TempDir())
+
+				Expect(run("set", "github.com.token", "s3cret")).To(Succeed())
+
+				conf := read()
+				Expect(conf.Github.Token).To(Equal("s3cret"))
+				Expect(conf.Github.Credential).To(BeNil())
+			})
+
+			g.It("should keep a token set in the keyring when one is available", func() {
+				if runtime.GOOS != "linux" {
+					return
+				}
+
+				bin := t.TempDir()
+				Expect(os.WriteFile(filepath.Join(bin, "secret-tool"), []byte("#!/bin/sh\n[ \"$1\" = store ] && cat > \"$(dirname \"$0\")/secret\"\n"), 0700)).To(Succeed())
+				t.Setenv("PATH", bin+string(os.PathListSeparator)+sysPath)
+
+				Expect(run("set", "github.com.token", "s3cret")).To(Succeed())
+
+				conf := read()
+				Expect(conf.Github.Token).To(BeEmpty())
+				Expect(conf.Github.Credential).To(Equal(&config.Credential{Store: "keyring", Key: "github.com"}))
+
+				b, err := os.ReadFile(filepath.Join(bin, "secret"))
+				Expect(err).To(BeNil())
+				Expect(string(b)).To(Equal("s3cret"))
+			})
+
+			g.It("should refuse to unset a token", func() {
+				err := run("unset", "github.com.token")
+				Expect(err).To(MatchError(ErrUnsetSecret))
+			})
+
+			g.It("should refuse adding to a field that is not a list", func() {
+				err := run("add", "release_branch", "main")
+				Expect(err).To(MatchError(config.ErrNotList))
+			})
+		})
+	})
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gomicro/train/client"
+	"github.com/google/go-github/github"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrNotTerminal = errors.New("refusing to prompt without a terminal, rerun with --yes to go ahead without confirming")
+	ErrBadPick     = errors.New("expected repo numbers or ranges, such as 1 3-5")
+)
+
+var (
+	assumeYes bool
+
+	// promptIn is where answers to prompts are read from
+	promptIn io.Reader = os.Stdin
+
+	// isTerminal reports whether there is someone at a terminal to prompt
+	isTerminal = func() bool {
+		return terminal(os.Stdin.Fd()) && terminal(os.Stdout.Fd())
+	}
+)
+
+// addConfirmFlags registers the flags controlling whether a command asks
+// before making changes.
+func addConfirmFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "make the changes without asking which repos to make them to, as when running unattended")
+}
+
+// confirming reports whether the user is to pick the changes a run makes
+// before they are made, failing if there is no one at a terminal to ask.
+func confirming() (bool, error) {
+	if dryRun || assumeYes {
+		return false, nil
+	}
+
+	if !isTerminal() {
+		return false, ErrNotTerminal
+	}
+
+	return true, nil
+}
+
+// confirmAndApply plans the changes the action would make to the repos, asks
+// the user which of them to make, and makes exactly those.
+func confirmAndApply(ctx context.Context, out io.Writer, action string, repos []*github.Repository) ([]string, error) {
+	progress, stopProgress := startProgress(ctx, out)
+
+	var changes []*client.Change
+	var err error
+	if action == client.ActionRelease {
+		changes, err = clt.PlanRelease(ctx, progress, repos)
+	} else {
+		changes, err = clt.PlanCreate(ctx, progress, repos)
+	}
+
+	stopProgress()
+
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintln(out)
+
+	if len(changes) < 1 {
+		fmt.Fprintln(out, "Nothing to change")
+		return nil, nil
+	}
+
+	changes, err = pickChanges(ctx, promptIn, out, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) < 1 {
+		fmt.Fprintln(out, "Nothing changed")
+		return nil, nil
+	}
+
+	fmt.Fprintln(out)
+
+	progress, stopProgress = startProgress(ctx, out)
+	defer stopProgress()
+
+	return clt.Apply(ctx, progress, changes)
+}
+
+// pickChanges lists the changes and lets the user toggle which of them to
+// make, returning those picked once the user goes ahead. Changes that are not
+// ready may not be picked. Nothing is picked if the user quits.
+func pickChanges(ctx context.Context, in io.Reader, out io.Writer, changes []*client.Change) ([]*client.Change, error) {
+	picked := make([]bool, len(changes))
+	for i, ch := range changes {
+		picked[i] = ch.Ready()
+	}
+
+	// the reader is left blocked on its next read once the picker returns,
+	// but never on handing over a line no one is waiting for
+	done := make(chan struct{})
+	defer close(done)
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		printChanges(out, changes, picked)
+
+		fmt.Fprintln(out)
+		fmt.Fprint(out, "Toggle repos by number, such as 1 3-5, a for all, n for none, y to go ahead, or q to quit: ")
+
+		var line string
+		var ok bool
+		select {
+		case line, ok = <-lines:
+		case <-ctx.Done():
+			fmt.Fprintln(out)
+			return nil, ctx.Err()
+		case <-client.Stopped(ctx):
+			fmt.Fprintln(out)
+			return nil, client.ErrInterrupted
+		}
+
+		if !ok {
+			fmt.Fprintln(out)
+			return nil, nil
+		}
+
+		fmt.Fprintln(out)
+
+		switch answer := strings.ToLower(strings.TrimSpace(line)); answer {
+		case "y", "yes":
+			var selected []*client.Change
+			for i, ch := range changes {
+				if picked[i] {
+					selected = append(selected, ch)
+				}
+			}
+
+			return selected, nil
+		case "q", "quit":
+			return nil, nil
+		case "a", "all":
+			for i, ch := range changes {
+				picked[i] = ch.Ready()
+			}
+		case "n", "none":
+			for i := range picked {
+				picked[i] = false
+			}
+		case "":
+		default:
+			nums, err := parsePicks(answer, len(changes))
+			if err != nil {
+				fmt.Fprintf(out, "%s\n\n", err)
+				continue
+			}
+
+			for _, n := range nums {
+				if !changes[n].Ready() {
+					fmt.Fprintf(out, "%s is %s, and may not be picked\n\n", changes[n].Repo, changes[n].MergeableState)
+					continue
+				}
+
+				picked[n] = !picked[n]
+			}
+		}
+	}
+}
+
+// printChanges lists the changes, numbered from one, marking those picked.
+func printChanges(out io.Writer, changes []*client.Change, picked []bool) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\t#\tREPO\tACTION\tCOMMITS\tMERGEABLE\tCHANGES")
+
+	for i, ch := range changes {
+		mark := "[ ]"
+		if picked[i] {
+			mark = "[x]"
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%d\t%s\t%s\n",
+			mark,
+			i+1,
+			ch.Repo,
+			ch.Action,
+			ch.Commits,
+			orDash(ch.MergeableState),
+			orDash(ch.Summary),
+		)
+	}
+
+	w.Flush()
+}
+
+// parsePicks returns the zero based indexes of the repo numbers and ranges
+// given, such as "1 3-5".
+func parsePicks(answer string, count int) ([]int, error) {
+	var nums []int
+	for _, f := range strings.FieldsFunc(answer, func(r rune) bool { return r == ' ' || r == ',' }) {
+		from, to, isRange := strings.Cut(f, "-")
+		if !isRange {
+			to = from
+		}
+
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("%w: got %s", ErrBadPick, f)
+		}
+
+		last, err := strconv.Atoi(to)
+		if err != nil {
+			return nil, fmt.Errorf("%w: got %s", ErrBadPick, f)
+		}
+
+		if first < 1 || last > count || first > last {
+			return nil, fmt.Errorf("%w: %s is not between 1 and %d", ErrBadPick, f, count)
+		}
+
+		for n := first; n <= last; n++ {
+			nums = append(nums, n-1)
+		}
+	}
+
+	return nums, nil
+}
+
+func terminal(fd uintptr) bool {
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
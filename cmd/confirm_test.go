@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/penname"
+	"github.com/gomicro/train/client"
+	"github.com/gomicro/train/client/clienttest"
+	"github.com/gomicro/train/journal"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestConfirm(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	repos := []*github.Repository{
+		{
+			Name:          github.String("steward"),
+			Owner:         &github.User{Login: github.String("gomicro")},
+			DefaultBranch: github.String("master"),
+		},
+		{
+			Name:          github.String("penname"),
+			Owner:         &github.User{Login: github.String("gomicro")},
+			DefaultBranch: github.String("master"),
+		},
+	}
+
+	newCreateCmd := func(w *penname.PenName) *cobra.Command {
+		cmd := NewCreateCmd(w)
+		cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+			clt = clienttest.New(&clienttest.Config{
+				BaseBranchName: "release",
+				Repos:          repos,
+			})
+
+			dryRun = viper.GetBool("dryRun")
+		}
+
+		return cmd
+	}
+
+	g.Describe("Confirm", func() {
+		g.BeforeEach(func() {
+			dir := t.TempDir()
+			journalDir = func() (string, error) { return dir, nil }
+		})
+
+		g.AfterEach(func() {
+			journalDir = journal.Dir
+			isTerminal = func() bool { return terminal(os.Stdin.Fd()) && terminal(os.Stdout.Fd()) }
+			promptIn = os.Stdin
+		})
+
+		g.It("should only change the repos picked", func() {
+			isTerminal = func() bool { return true }
+			promptIn = strings.NewReader("2\ny\n")
+
+			w := penname.New()
+			cmd := newCreateCmd(w)
+			cmd.SetArgs([]string{"gomicro"})
+			Expect(cmd.Execute()).To(BeNil())
+
+			cmdOut := string(w.Written())
+			Expect(cmdOut).To(ContainSubstring("[x]  2  gomicro/penname  create  2        -          1 added, 1 fixed"))
+			Expect(cmdOut).To(ContainSubstring("[ ]  2  gomicro/penname"))
+			Expect(cmdOut).To(ContainSubstring("Release PRs Created:\nhttps://github.com/gomicro/steward/pull/0\n"))
+			Expect(cmdOut).NotTo(ContainSubstring("https://github.com/gomicro/penname/pull"))
+		})
+
+		g.It("should change nothing when the user quits", func() {
+			isTerminal = func() bool { return true }
+			promptIn = strings.NewReader("q\n")
+
+			w := penname.New()
+			cmd := newCreateCmd(w)
+			cmd.SetArgs([]string{"gomicro"})
+			Expect(cmd.Execute()).To(BeNil())
+
+			cmdOut := string(w.Written())
+			Expect(cmdOut).To(ContainSubstring("Nothing changed"))
+			Expect(cmdOut).NotTo(ContainSubstring("Release PRs Created:"))
+		})
+
+		g.It("should refuse to prompt without a terminal", func() {
+			isTerminal = func() bool { return false }
+
+			cmd := newCreateCmd(penname.New())
+			cmd.SetArgs([]string{"gomicro"})
+			err := cmd.Execute()
+			Expect(errors.Is(err, ErrNotTerminal)).To(BeTrue())
+		})
+
+		g.It("should stop waiting for picks when the run is asked to stop", func() {
+			in, answers := io.Pipe()
+			defer answers.Close()
+
+			stop := make(chan struct{})
+			close(stop)
+
+			ctx := client.WithStop(context.Background(), stop)
+
+			picked, err := pickChanges(ctx, in, penname.New(), []*client.Change{
+				{Action: client.ChangeCreate, Repo: "gomicro/steward"},
+			})
+			Expect(errors.Is(err, client.ErrInterrupted)).To(BeTrue())
+			Expect(picked).To(BeEmpty())
+
+			// a line typed after the picker returns is read, but not waited on
+			_, err = answers.Write([]byte("y\n"))
+			Expect(err).To(BeNil())
+		})
+
+		g.It("should parse repo numbers and ranges", func() {
+			nums, err := parsePicks("1 3-4,6", 6)
+			Expect(err).To(BeNil())
+			Expect(nums).To(Equal([]int{0, 2, 3, 5}))
+
+			_, err = parsePicks("0-2", 6)
+			Expect(errors.Is(err, ErrBadPick)).To(BeTrue())
+		})
+	})
+}
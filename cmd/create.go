@@ -6,7 +6,7 @@ import (
 	"io"
 	"os"
 
-	"github.com/gomicro/crawl"
+	"github.com/gomicro/train/client"
 	"github.com/spf13/cobra"
 )
 
@@ -18,66 +18,118 @@ func NewCreateCmd(out io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:               "create [org_name|user_name]",
 		Short:             "Create release PRs for an org or user's repos",
-		Args:              cobra.ExactArgs(1),
+		Args:              entityArgs,
 		PersistentPreRun:  setupClient,
 		RunE:              createRun(out),
 		ValidArgsFunction: createCmdValidArgsFunc,
 	}
 
+	addRepoFlags(cmd)
+	addBudgetFlags(cmd)
+	addRunFlags(cmd)
+	addConfirmFlags(cmd)
+
 	return cmd
 }
 
 func createRun(out io.Writer) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		return create(cmd, out, flagSelection(args))
+	}
+}
 
-		progress := crawl.New(ctx, out)
-		progress.SetOut(out)
-		progress.Start()
+// create opens release PRs for the repos selected.
+func create(cmd *cobra.Command, out io.Writer, sel *selection) error {
+	ctx, cancel := runContext(os.Stderr)
+	defer cancel()
 
-		entity := args[0]
+	err := clt.CheckAuth(ctx)
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("create: %w", err)
+	}
 
-		fmt.Fprintf(out, "Entity: %s\n", entity)
-		fmt.Fprintf(out, "Base: %s\n", clt.GetBaseBranchName())
+	confirm, err := confirming()
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("create: %w", err)
+	}
 
-		if dryRun {
-			fmt.Fprintln(out)
-			fmt.Fprintln(out, "===============")
-			fmt.Fprintln(out, "Doing a dry run")
-			fmt.Fprintln(out, "===============")
-		}
+	progress, stopProgress := startProgress(ctx, out)
+	defer stopProgress()
 
+	fmt.Fprintln(out, sel.describe())
+	fmt.Fprintf(out, "Base: %s\n", clt.GetBaseBranchName())
+
+	if dryRun {
 		fmt.Fprintln(out)
+		fmt.Fprintln(out, "===============")
+		fmt.Fprintln(out, "Doing a dry run")
+		fmt.Fprintln(out, "===============")
+	}
 
-		repos, err := clt.GetRepos(ctx, progress, entity)
-		if err != nil {
-			cmd.SilenceUsage = true
-			return fmt.Errorf("create: %w", err)
-		}
+	fmt.Fprintln(out)
 
-		urls, err := clt.ProcessRepos(ctx, progress, repos, dryRun)
-		if err != nil {
-			cmd.SilenceUsage = true
-			return fmt.Errorf("create: %w", err)
+	repos, err := fetchRepos(ctx, progress, sel)
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("create: %w", err)
+	}
+
+	j, err := startJournal(client.ActionCreate, sel)
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("create: %w", err)
+	}
+
+	if j != nil {
+		repos = j.Pending(repos)
+		clt.SetRecorder(j)
+	}
+
+	est, err := checkBudget(ctx, client.ActionCreate, repos)
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("create: %w", finishJournal(j, err))
+	}
+
+	var urls []string
+	if len(repos) > 0 {
+		if confirm {
+			stopProgress()
+			urls, err = confirmAndApply(ctx, out, client.ActionCreate, repos)
+		} else {
+			urls, err = clt.ProcessRepos(ctx, progress, repos, dryRun)
 		}
+	}
 
-		progress.Stop()
+	stopProgress()
 
-		if len(urls) > 0 {
-			fmt.Fprintln(out)
-			if dryRun {
-				fmt.Fprintln(out, "(Dryrun) Release PRs Created:")
-			} else {
-				fmt.Fprintln(out, "Release PRs Created:")
-			}
+	printEstimate(out, est)
 
-			for _, url := range urls {
-				fmt.Fprintln(out, url)
-			}
+	if len(urls) > 0 {
+		fmt.Fprintln(out)
+		switch {
+		case dryRun:
+			fmt.Fprintln(out, "(Dryrun) Release PRs Created:")
+		case err != nil:
+			fmt.Fprintln(out, "Release PRs Created before the run stopped:")
+		default:
+			fmt.Fprintln(out, "Release PRs Created:")
 		}
 
-		return nil
+		for _, url := range urls {
+			fmt.Fprintln(out, url)
+		}
 	}
+
+	err = finishJournal(j, err)
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("create: %w", err)
+	}
+
+	return nil
 }
 
 func createCmdValidArgsFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -7,7 +7,9 @@ import (
 
 	"github.com/franela/goblin"
 	"github.com/gomicro/penname"
+	"github.com/gomicro/train/client"
 	"github.com/gomicro/train/client/clienttest"
+	"github.com/gomicro/train/journal"
 	"github.com/google/go-github/github"
 	. "github.com/onsi/gomega"
 	"github.com/spf13/cobra"
@@ -19,6 +21,15 @@ func TestCreateCmd(t *testing.T) {
 	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
 
 	g.Describe("Create", func() {
+		g.BeforeEach(func() {
+			dir := t.TempDir()
+			journalDir = func() (string, error) { return dir, nil }
+		})
+
+		g.AfterEach(func() {
+			journalDir = journal.Dir
+		})
+
 		g.It("should create prs for org repos", func() {
 			w := penname.New()
 
@@ -41,7 +52,7 @@ func TestCreateCmd(t *testing.T) {
 				dryRun = viper.GetBool("dryRun")
 			}
 
-			cmd.SetArgs([]string{"gomicro"})
+			cmd.SetArgs([]string{"gomicro", "--yes"})
 			err := cmd.Execute()
 			Expect(err).To(BeNil())
 			cmdOut := string(w.Written())
@@ -57,5 +68,130 @@ func TestCreateCmd(t *testing.T) {
 
 			Expect(cmdOut).To(Equal("\nRelease PRs Created:\n\nhttps://github.com/gomicro/steward/pull/0\n"))
 		})
+
+		g.It("should create prs for team repos", func() {
+			w := penname.New()
+
+			cmd := NewCreateCmd(w)
+			cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+				clt = clienttest.New(&clienttest.Config{
+					BaseBranchName: "release",
+					Repos: []*github.Repository{
+						{
+							Name: github.String("steward"),
+							Owner: &github.User{
+								Login: github.String("gomicro"),
+							},
+							DefaultBranch: github.String("master"),
+						},
+					},
+				})
+
+				dryRun = viper.GetBool("dryRun")
+			}
+			defer func() { team = "" }()
+
+			cmd.SetArgs([]string{"--team", "gomicro/core", "--yes"})
+			err := cmd.Execute()
+			Expect(err).To(BeNil())
+			cmdOut := string(w.Written())
+
+			teamOut := "Team: gomicro/core\n"
+			Expect(strings.HasPrefix(cmdOut, teamOut)).To(BeTrue(), fmt.Sprintf("missing team out line in output: got %s", cmdOut))
+		})
+
+		g.It("should create prs for repos matching a query", func() {
+			w := penname.New()
+
+			cmd := NewCreateCmd(w)
+			cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+				clt = clienttest.New(&clienttest.Config{
+					BaseBranchName: "release",
+					Repos: []*github.Repository{
+						{
+							Name: github.String("steward"),
+							Owner: &github.User{
+								Login: github.String("gomicro"),
+							},
+							DefaultBranch: github.String("master"),
+						},
+					},
+				})
+
+				dryRun = viper.GetBool("dryRun")
+			}
+			defer func() { query = "" }()
+
+			cmd.SetArgs([]string{"--query", "org:gomicro topic:service", "--yes"})
+			err := cmd.Execute()
+			Expect(err).To(BeNil())
+			cmdOut := string(w.Written())
+
+			queryOut := "Query: org:gomicro topic:service\n"
+			Expect(strings.HasPrefix(cmdOut, queryOut)).To(BeTrue(), fmt.Sprintf("missing query out line in output: got %s", cmdOut))
+		})
+
+		g.It("should fail before fetching repos when not logged in", func() {
+			w := penname.New()
+
+			cmd := NewCreateCmd(w)
+			cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+				clt = clienttest.New(&clienttest.Config{
+					AuthError:  client.ErrNoToken,
+					ReposError: fmt.Errorf("should not be called"),
+				})
+			}
+
+			cmd.SetArgs([]string{"gomicro"})
+			err := cmd.Execute()
+			Expect(err).To(MatchError(client.ErrNoToken))
+			Expect(string(w.Written())).To(BeEmpty())
+		})
+
+		g.It("should refuse a run that would exhaust the rate limit", func() {
+			w := penname.New()
+
+			cmd := NewCreateCmd(w)
+			cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+				clt = clienttest.New(&clienttest.Config{
+					BaseBranchName: "release",
+					Estimate: &client.CostEstimate{
+						Calls:     40,
+						Remaining: 10,
+						Limit:     5000,
+					},
+					Repos: []*github.Repository{
+						{
+							Name: github.String("steward"),
+							Owner: &github.User{
+								Login: github.String("gomicro"),
+							},
+							DefaultBranch: github.String("master"),
+						},
+					},
+					ProcessReposError: fmt.Errorf("should not be called"),
+				})
+
+				dryRun = viper.GetBool("dryRun")
+			}
+
+			cmd.SetArgs([]string{"gomicro", "--yes"})
+			err := cmd.Execute()
+			Expect(err).To(MatchError(client.ErrBudgetExceeded))
+		})
+
+		g.It("should reject a malformed team", func() {
+			w := penname.New()
+
+			cmd := NewCreateCmd(w)
+			cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+				clt = clienttest.New(&clienttest.Config{})
+			}
+			defer func() { team = "" }()
+
+			cmd.SetArgs([]string{"--team", "gomicro", "--yes"})
+			err := cmd.Execute()
+			Expect(err).To(MatchError(ErrBadTeam))
+		})
 	})
 }
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	deviceGrantType       = "urn:ietf:params:oauth:grant-type:device_code"
+	defaultDevicePollSecs = 5
+	slowDownSecs          = 5
+)
+
+var (
+	ErrDeviceExpired = errors.New("device code expired before it was authorized")
+	ErrDeviceDenied  = errors.New("authorization was denied")
+)
+
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+type deviceToken struct {
+	AccessToken string `json:"access_token"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// deviceFlow authorizes train with the OAuth device flow. It prints a code
+// for the user to enter at the verification URL on any device, then polls the
+// token endpoint until the code is authorized, denied, or expires.
+func deviceFlow(ctx context.Context, out io.Writer, httpClient *http.Client, codeURL, tokenURL string) (string, error) {
+	var code deviceCode
+	err := postForm(ctx, httpClient, codeURL, url.Values{
+		"client_id": {clientID},
+		"scope":     {"repo"},
+	}, &code)
+	if err != nil {
+		return "", fmt.Errorf("device code: %w", err)
+	}
+
+	fmt.Fprintf(out, "First copy your one-time code: %s\n", code.UserCode)
+	fmt.Fprintf(out, "Then visit %s on any device to authorize train\n", code.VerificationURI)
+
+	if code.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(code.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	interval := code.Interval
+	if interval < 1 {
+		interval = defaultDevicePollSecs
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ErrDeviceExpired
+		case <-time.After(time.Duration(interval) * time.Second):
+		}
+
+		var tkn deviceToken
+		err := postForm(ctx, httpClient, tokenURL, url.Values{
+			"client_id":   {clientID},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {deviceGrantType},
+		}, &tkn)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ErrDeviceExpired
+			}
+
+			return "", fmt.Errorf("device token: %w", err)
+		}
+
+		switch tkn.Error {
+		case "":
+			if tkn.AccessToken == "" {
+				return "", fmt.Errorf("device token: empty token returned")
+			}
+
+			return tkn.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += slowDownSecs
+		case "expired_token":
+			return "", ErrDeviceExpired
+		case "access_denied":
+			return "", ErrDeviceDenied
+		default:
+			return "", fmt.Errorf("device token: %s: %s", tkn.Error, tkn.Description)
+		}
+	}
+}
+
+func postForm(ctx context.Context, httpClient *http.Client, u string, form url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
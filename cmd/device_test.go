@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/penname"
+	. "github.com/onsi/gomega"
+)
+
+func TestDeviceFlow(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Device Flow", func() {
+		g.It("should poll until the code is authorized", func() {
+			polls := 0
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/code", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"device_code":"dc","user_code":"ABCD-1234","verification_uri":"https://example.com/device","expires_in":30,"interval":1}`)
+			})
+			mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.FormValue("device_code")).To(Equal("dc"))
+				Expect(r.FormValue("grant_type")).To(Equal(deviceGrantType))
+
+				polls++
+				if polls < 2 {
+					fmt.Fprint(w, `{"error":"authorization_pending"}`)
+					return
+				}
+
+				fmt.Fprint(w, `{"access_token":"tkn"}`)
+			})
+
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			w := penname.New()
+
+			tkn, err := deviceFlow(context.Background(), w, srv.Client(), srv.URL+"/code", srv.URL+"/token")
+			Expect(err).To(BeNil())
+			Expect(tkn).To(Equal("tkn"))
+			Expect(polls).To(Equal(2))
+			Expect(string(w.Written())).To(ContainSubstring("ABCD-1234"))
+			Expect(string(w.Written())).To(ContainSubstring("https://example.com/device"))
+		})
+
+		g.It("should stop when authorization is denied", func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/code", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"device_code":"dc","user_code":"ABCD-1234","verification_uri":"https://example.com/device","expires_in":30,"interval":1}`)
+			})
+			mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"error":"access_denied"}`)
+			})
+
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			_, err := deviceFlow(context.Background(), penname.New(), srv.Client(), srv.URL+"/code", srv.URL+"/token")
+			Expect(err).To(MatchError(ErrDeviceDenied))
+		})
+	})
+}
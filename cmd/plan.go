@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gomicro/train/client"
+	"github.com/gomicro/train/plan"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	planOutput  string
+	planRelease bool
+)
+
+func init() {
+	rootCmd.AddCommand(NewPlanCmd(os.Stdout))
+}
+
+func NewPlanCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "plan [org_name|user_name]",
+		Short:             "Plan the release PRs to create or merge for an org or user's repos",
+		Long:              `Plan the release PRs a create would open or edit, or with --release the release PRs a release would merge, pinned to the commits each repo is at now. The plan is written to a file to be reviewed, then carried out with train apply.`,
+		Args:              entityArgs,
+		PersistentPreRun:  setupClient,
+		RunE:              planRun(out),
+		ValidArgsFunction: createCmdValidArgsFunc,
+	}
+
+	addRepoFlags(cmd)
+	addRunFlags(cmd)
+
+	cmd.Flags().StringVarP(&planOutput, "output", "o", "plan.json", "file to write the plan to")
+	cmd.Flags().BoolVar(&planRelease, "release", false, "plan merging the release PRs, rather than creating them")
+
+	return cmd
+}
+
+func planRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := runContext(os.Stderr)
+		defer cancel()
+
+		err := clt.CheckAuth(ctx)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("plan: %w", err)
+		}
+
+		progress, stopProgress := startProgress(ctx, out)
+		defer stopProgress()
+
+		sel := flagSelection(args)
+
+		fmt.Fprintln(out, sel.describe())
+		fmt.Fprintln(out)
+
+		repos, err := fetchRepos(ctx, progress, sel)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("plan: %w", err)
+		}
+
+		p := &plan.Plan{
+			Action:  client.ActionCreate,
+			Entity:  sel.entity(),
+			Profile: viper.GetString("profile"),
+			Created: time.Now(),
+		}
+
+		if planRelease {
+			p.Action = client.ActionRelease
+			p.Changes, err = clt.PlanRelease(ctx, progress, repos)
+		} else {
+			p.Changes, err = clt.PlanCreate(ctx, progress, repos)
+		}
+
+		stopProgress()
+
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("plan: %w", err)
+		}
+
+		var skipped []*client.Change
+		p.Changes, skipped = readyChanges(p.Changes)
+
+		fmt.Fprintln(out)
+
+		if len(skipped) > 0 {
+			fmt.Fprintln(out, "Not Mergeable:")
+			for _, ch := range skipped {
+				fmt.Fprintf(out, "%s (%s)\n", ch.URL, ch.MergeableState)
+			}
+
+			fmt.Fprintln(out)
+		}
+
+		if len(p.Changes) < 1 {
+			fmt.Fprintln(out, "Nothing to change")
+			return nil
+		}
+
+		fmt.Fprintln(out, "Planned Changes:")
+		for _, ch := range p.Changes {
+			fmt.Fprintln(out, ch)
+		}
+
+		err = plan.Write(planOutput, p)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("plan: %w", err)
+		}
+
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "Plan written to %s, carry it out with `train apply %s`\n", planOutput, planOutput)
+
+		return nil
+	}
+}
+
+// readyChanges splits the changes into those that may be made, and those that
+// may not yet.
+func readyChanges(changes []*client.Change) ([]*client.Change, []*client.Change) {
+	var ready, notReady []*client.Change
+	for _, ch := range changes {
+		if ch.Ready() {
+			ready = append(ready, ch)
+			continue
+		}
+
+		notReady = append(notReady, ch)
+	}
+
+	return ready, notReady
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/penname"
+	"github.com/gomicro/train/client"
+	"github.com/gomicro/train/client/clienttest"
+	"github.com/gomicro/train/plan"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestPlan(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	repos := []*github.Repository{
+		{
+			Name:          github.String("steward"),
+			Owner:         &github.User{Login: github.String("gomicro")},
+			DefaultBranch: github.String("master"),
+		},
+		{
+			Name:          github.String("penname"),
+			Owner:         &github.User{Login: github.String("gomicro")},
+			DefaultBranch: github.String("master"),
+		},
+	}
+
+	g.Describe("Plan", func() {
+		var file string
+
+		g.BeforeEach(func() {
+			file = filepath.Join(t.TempDir(), "plan.json")
+		})
+
+		g.It("should write the planned changes to a file", func() {
+			w := penname.New()
+
+			cmd := NewPlanCmd(w)
+			cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+				clt = clienttest.New(&clienttest.Config{
+					BaseBranchName: "release",
+					Repos:          repos,
+				})
+
+				dryRun = viper.GetBool("dryRun")
+			}
+
+			cmd.SetArgs([]string{"gomicro", "-o", file})
+			Expect(cmd.Execute()).To(BeNil())
+			Expect(string(w.Written())).To(ContainSubstring("create gomicro/steward master (1111111) into release (2222222)"))
+
+			p, err := plan.Read(file)
+			Expect(err).To(BeNil())
+			Expect(p.Action).To(Equal(client.ActionCreate))
+			Expect(p.Entity).To(Equal("gomicro"))
+			Expect(p.Changes).To(HaveLen(2))
+		})
+
+		g.It("should refuse to apply changes to repos that moved on", func() {
+			err := plan.Write(file, &plan.Plan{
+				Action: client.ActionCreate,
+				Entity: "gomicro",
+				Changes: []*client.Change{
+					{Action: client.ChangeCreate, Repo: "gomicro/penname", Head: "master", Base: "release"},
+					{Action: client.ChangeCreate, Repo: "gomicro/steward", Head: "master", Base: "release"},
+				},
+			})
+			Expect(err).To(BeNil())
+
+			w := penname.New()
+			cmd := NewApplyCmd(w, func(cmd *cobra.Command, args []string) {
+				Expect(args).To(Equal([]string{"gomicro"}))
+
+				clt = clienttest.New(&clienttest.Config{
+					Changed: []string{"gomicro/penname"},
+				})
+			})
+
+			cmd.SetArgs([]string{file})
+			err = cmd.Execute()
+			Expect(errors.Is(err, client.ErrStale)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("gomicro/penname"))
+
+			cmdOut := string(w.Written())
+			Expect(cmdOut).To(ContainSubstring("Changes Applied:\nhttps://github.com/gomicro/steward/pull/1\n"))
+			Expect(cmdOut).NotTo(ContainSubstring("https://github.com/gomicro/penname"))
+		})
+	})
+}
@@ -5,27 +5,51 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/gomicro/crawl"
+	"github.com/gomicro/train/client"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	rootCmd.AddCommand(releaseCmd)
+
+	addRepoFlags(releaseCmd)
+	addBudgetFlags(releaseCmd)
+	addRunFlags(releaseCmd)
+	addConfirmFlags(releaseCmd)
 }
 
 var releaseCmd = &cobra.Command{
 	Use:               "release [org_name|user_name]",
 	Short:             "Release PRs for an org or user's repos that can be merged",
+	Args:              entityArgs,
 	PersistentPreRun:  setupClient,
 	RunE:              releaseFunc,
 	ValidArgsFunction: releaseCmdValidArgsFunc,
 }
 
 func releaseFunc(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	return release(cmd, flagSelection(args))
+}
 
-	progress := crawl.New(ctx, os.Stdout)
-	progress.Start()
+// release merges the release PRs for the repos selected that can be merged.
+func release(cmd *cobra.Command, sel *selection) error {
+	ctx, cancel := runContext(os.Stderr)
+	defer cancel()
+
+	err := clt.CheckAuth(ctx)
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("release: %w", err)
+	}
+
+	confirm, err := confirming()
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("release: %w", err)
+	}
+
+	progress, stopProgress := startProgress(ctx, os.Stdout)
+	defer stopProgress()
 
 	if dryRun {
 		fmt.Println()
@@ -36,25 +60,51 @@ func releaseFunc(cmd *cobra.Command, args []string) error {
 
 	fmt.Println()
 
-	repos, err := clt.GetRepos(ctx, progress, args[0])
+	repos, err := fetchRepos(ctx, progress, sel)
 	if err != nil {
 		cmd.SilenceUsage = true
 		return fmt.Errorf("release: %w", err)
 	}
 
-	urls, err := clt.ReleaseRepos(ctx, progress, repos, dryRun)
+	j, err := startJournal(client.ActionRelease, sel)
 	if err != nil {
 		cmd.SilenceUsage = true
 		return fmt.Errorf("release: %w", err)
 	}
 
-	progress.Stop()
+	if j != nil {
+		repos = j.Pending(repos)
+		clt.SetRecorder(j)
+	}
+
+	est, err := checkBudget(ctx, client.ActionRelease, repos)
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("release: %w", finishJournal(j, err))
+	}
+
+	var urls []string
+	if len(repos) > 0 {
+		if confirm {
+			stopProgress()
+			urls, err = confirmAndApply(ctx, os.Stdout, client.ActionRelease, repos)
+		} else {
+			urls, err = clt.ReleaseRepos(ctx, progress, repos, dryRun)
+		}
+	}
+
+	stopProgress()
+
+	printEstimate(os.Stdout, est)
 
 	if len(urls) > 0 {
 		fmt.Println()
-		if dryRun {
+		switch {
+		case dryRun:
 			fmt.Println("(Dryrun) Repos Released:")
-		} else {
+		case err != nil:
+			fmt.Println("Repos Released before the run stopped:")
+		default:
 			fmt.Println("Repos Released:")
 		}
 
@@ -63,6 +113,12 @@ func releaseFunc(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	err = finishJournal(j, err)
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("release: %w", err)
+	}
+
 	return nil
 }
 
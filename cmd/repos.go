@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gomicro/crawl"
+	"github.com/google/go-github/github"
+	"github.com/spf13/cobra"
+)
+
+var (
+	team           string
+	teamPermission string
+	query          string
+
+	ErrBadTeam = fmt.Errorf("team must be of the form org/team-slug")
+)
+
+// addRepoFlags registers the flags used for selecting which repos a command
+// acts upon.
+func addRepoFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&team, "team", "", "act on the repos a team has access to, in the form org/team-slug")
+	cmd.Flags().StringVar(&teamPermission, "team-permission", "", "only act on team repos with at least this permission (admin, maintain, push)")
+
+	cmd.Flags().StringVar(&query, "query", "", "act on the repos matching a GitHub repository search query")
+
+	cmd.MarkFlagsMutuallyExclusive("team", "query")
+
+	cmd.RegisterFlagCompletionFunc("team", teamFlagCompletionFunc)                      //nolint: errcheck
+	cmd.RegisterFlagCompletionFunc("team-permission", teamPermissionFlagCompletionFunc) //nolint: errcheck
+}
+
+// entityArgs requires a single org or user name, unless the repos are being
+// selected by another means.
+func entityArgs(cmd *cobra.Command, args []string) error {
+	if team != "" || query != "" {
+		return cobra.MaximumNArgs(0)(cmd, args)
+	}
+
+	return cobra.ExactArgs(1)(cmd, args)
+}
+
+// selection represents where the repos a run acts upon are sourced from.
+type selection struct {
+	args           []string
+	team           string
+	teamPermission string
+	query          string
+}
+
+// flagSelection returns the selection made by the args and flags given.
+func flagSelection(args []string) *selection {
+	return &selection{
+		args:           args,
+		team:           team,
+		teamPermission: teamPermission,
+		query:          query,
+	}
+}
+
+// entity returns the org, user, or team the selection acts upon, used for
+// picking a profile, or an empty string for a query.
+func (s *selection) entity() string {
+	if len(s.args) > 0 {
+		return s.args[0]
+	}
+
+	return s.team
+}
+
+// describe returns the line describing where repos are sourced from.
+func (s *selection) describe() string {
+	if s.team != "" {
+		return fmt.Sprintf("Team: %s", s.team)
+	}
+
+	if s.query != "" {
+		return fmt.Sprintf("Query: %s", s.query)
+	}
+
+	return fmt.Sprintf("Entity: %s", s.args[0])
+}
+
+// fetchRepos collects the repos to act upon from the source selected.
+func fetchRepos(ctx context.Context, progress *crawl.Progress, sel *selection) ([]*github.Repository, error) {
+	if sel.team != "" {
+		org, slug, err := splitTeam(sel.team)
+		if err != nil {
+			return nil, err
+		}
+
+		return clt.GetTeamRepos(ctx, progress, org, slug, sel.teamPermission)
+	}
+
+	if sel.query != "" {
+		return clt.SearchRepos(ctx, progress, sel.query)
+	}
+
+	return clt.GetRepos(ctx, progress, sel.args[0])
+}
+
+func splitTeam(t string) (string, string, error) {
+	parts := strings.Split(t, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("%w: %s", ErrBadTeam, t)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+func teamFlagCompletionFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	setupClient(cmd, args)
+
+	org, _, found := strings.Cut(toComplete, "/")
+	if !found {
+		logins, err := clt.GetLogins(context.Background())
+		if err != nil {
+			return []string{"error fetching"}, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		valid := make([]string, 0, len(logins))
+		for _, l := range logins {
+			valid = append(valid, l+"/")
+		}
+
+		return valid, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+
+	slugs, err := clt.GetTeams(context.Background(), org)
+	if err != nil {
+		return []string{"error fetching"}, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	valid := make([]string, 0, len(slugs))
+	for _, s := range slugs {
+		valid = append(valid, fmt.Sprintf("%s/%s", org, s))
+	}
+
+	return valid, cobra.ShellCompDirectiveNoFileComp
+}
+
+func teamPermissionFlagCompletionFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{"admin", "maintain", "push"}, cobra.ShellCompDirectiveNoFileComp
+}
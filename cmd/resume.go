@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gomicro/train/client"
+	"github.com/gomicro/train/journal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var ErrResumeDryRun = errors.New("runs may only be resumed live, rerun without --dryRun")
+
+func init() {
+	rootCmd.AddCommand(NewResumeCmd(os.Stdout, setupClient))
+}
+
+func NewResumeCmd(out io.Writer, setupFunc func(*cobra.Command, []string)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "resume <run-id>",
+		Short:             "Resume an interrupted create or release run",
+		Long:              `Resume a create or release run that was interrupted, skipping the repos it already finished.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              resumeRun(out, setupFunc),
+		ValidArgsFunction: runIDValidArgsFunc,
+	}
+
+	addBudgetFlags(cmd)
+	addRunFlags(cmd)
+	addConfirmFlags(cmd)
+
+	cmd.SetOut(out)
+
+	return cmd
+}
+
+func resumeRun(out io.Writer, setupFunc func(*cobra.Command, []string)) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		// dry runs are not recorded, so there is nothing a dry run could
+		// carry on from
+		if viper.GetBool("dryRun") {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("resume: %w", ErrResumeDryRun)
+		}
+
+		dir, err := journalDir()
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("resume: %w", err)
+		}
+
+		run, err := journal.Read(dir, args[0])
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("resume: %w", err)
+		}
+
+		if run.Status() == journal.StatusComplete {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("resume: %w: %s", journal.ErrComplete, run.Header.ID)
+		}
+
+		h := run.Header
+
+		sel := &selection{
+			args:           h.Args,
+			team:           h.Team,
+			teamPermission: h.TeamPermission,
+			query:          h.Query,
+		}
+
+		if !viper.IsSet("profile") {
+			viper.Set("profile", h.Profile)
+		}
+
+		var entity []string
+		if sel.entity() != "" {
+			entity = []string{sel.entity()}
+		}
+
+		setupFunc(cmd, entity)
+
+		settings, err := runSettings()
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("resume: %w", err)
+		}
+
+		j, err := journal.Resume(dir, h.ID, settings)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("resume: %w", err)
+		}
+
+		resuming = j
+		defer func() { resuming = nil }()
+
+		fmt.Fprintf(out, "Resuming run %s\n", h.ID)
+
+		switch h.Action {
+		case client.ActionCreate:
+			return create(cmd, out, sel)
+		case client.ActionRelease:
+			return release(cmd, sel)
+		default:
+			err = fmt.Errorf("%w: %s", client.ErrUnknownAction, h.Action)
+			j.Finish(err) //nolint: errcheck
+
+			cmd.SilenceUsage = true
+			return fmt.Errorf("resume: %w", err)
+		}
+	}
+}
@@ -2,10 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 
+	"github.com/gomicro/train/audit"
+	"github.com/gomicro/train/cache"
 	"github.com/gomicro/train/client"
 	"github.com/gomicro/train/config"
+	"github.com/gomicro/train/credential"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -13,6 +17,9 @@ import (
 var (
 	clt    client.Clienter
 	dryRun bool
+
+	// conf is the effective config the client was set up with
+	conf *config.Config
 )
 
 func init() {
@@ -20,6 +27,9 @@ func init() {
 
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "show more verbose output")
 	rootCmd.PersistentFlags().BoolP("dryRun", "d", false, "attempt the specified command without actually making live changes")
+	rootCmd.PersistentFlags().String("config", "", "config file to layer over the user and project config files")
+	rootCmd.PersistentFlags().String("profile", "", "named profile from the config file to use")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "fetch everything from github instead of revalidating cached responses")
 
 	err := viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 	if err != nil {
@@ -32,9 +42,45 @@ func init() {
 		fmt.Printf("Error setting up: %s\n", err)
 		os.Exit(1)
 	}
+
+	err = viper.BindPFlag("config", rootCmd.PersistentFlags().Lookup("config"))
+	if err != nil {
+		fmt.Printf("Error setting up: %s\n", err)
+		os.Exit(1)
+	}
+
+	err = viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+	if err != nil {
+		fmt.Printf("Error setting up: %s\n", err)
+		os.Exit(1)
+	}
+
+	err = viper.BindPFlag("noCache", rootCmd.PersistentFlags().Lookup("no-cache"))
+	if err != nil {
+		fmt.Printf("Error setting up: %s\n", err)
+		os.Exit(1)
+	}
+
+	for _, f := range config.Fields {
+		rootCmd.PersistentFlags().String(f.Flag, "", fmt.Sprintf("override %s: %s", f.Path, f.Usage))
+
+		err = viper.BindPFlag(f.Flag, rootCmd.PersistentFlags().Lookup(f.Flag))
+		if err != nil {
+			fmt.Printf("Error setting up: %s\n", err)
+			os.Exit(1)
+		}
+
+		err = viper.BindEnv(append([]string{f.Flag}, f.Env...)...)
+		if err != nil {
+			fmt.Printf("Error setting up: %s\n", err)
+			os.Exit(1)
+		}
+	}
 }
 
 func initEnvs() {
+	viper.SetEnvPrefix("train")
+	viper.AutomaticEnv()
 }
 
 var rootCmd = &cobra.Command{
@@ -51,17 +97,80 @@ func Execute() {
 }
 
 func setupClient(cmd *cobra.Command, args []string) {
-	c, err := config.ParseFromFile()
+	c, err := loadConfig(flagSelection(args).entity())
 	if err != nil {
 		fmt.Printf("Error: %s", err)
 		os.Exit(1)
 	}
 
-	clt, err = client.New(c)
+	err = credential.Resolve(c)
 	if err != nil {
-		fmt.Printf("Error: %s", err)
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	clt, err = newClient(c)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
 		os.Exit(1)
 	}
 
+	conf = c
+
 	dryRun = viper.GetBool("dryRun")
 }
+
+// newClient validates the effective config given and returns a client for
+// it, auditing every change it makes.
+func newClient(c *config.Config) (*client.Client, error) {
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	var verbose io.Writer = io.Discard
+	if viper.GetBool("verbose") {
+		verbose = os.Stderr
+	}
+
+	var cch *cache.Cache
+	if !viper.GetBool("noCache") {
+		dir, err := cache.Dir()
+		if err != nil {
+			return nil, err
+		}
+
+		cch = cache.New(dir, cache.DefaultMaxSize)
+	}
+
+	nc, err := client.New(c, verbose, cch)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLog, err := auditPath(c)
+	if err != nil {
+		return nil, err
+	}
+
+	nc.SetAuditor(audit.New(auditLog, os.Stdout))
+
+	return nc, nil
+}
+
+// loadConfig loads the effective config for the entity given, applying any
+// overrides set through flags or environment variables.
+func loadConfig(entity string) (*config.Config, error) {
+	return config.Load(&config.LoadOptions{
+		Path:    viper.GetString("config"),
+		Profile: viper.GetString("profile"),
+		Entity:  entity,
+		Lookup: func(f *config.Field) (string, bool) {
+			if !viper.IsSet(f.Flag) {
+				return "", false
+			}
+
+			return viper.GetString(f.Flag), true
+		},
+	})
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestLoadConfig(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("loadConfig", func() {
+		var flags []string
+
+		setFlag := func(name, value string) {
+			Expect(rootCmd.PersistentFlags().Set(name, value)).To(Succeed())
+			flags = append(flags, name)
+		}
+
+		g.AfterEach(func() {
+			for _, name := range flags {
+				f := rootCmd.PersistentFlags().Lookup(name)
+				f.Value.Set(f.DefValue) //nolint: errcheck
+				f.Changed = false
+			}
+
+			flags = nil
+		})
+
+		g.BeforeEach(func() {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+
+			path := filepath.Join(home, ".train", "config")
+			Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+			Expect(os.WriteFile(path, []byte("version: 1\nrelease_branch: file\ngithub.com:\n  token: file-token\n"), 0600)).To(Succeed())
+
+			for _, env := range []string{"TRAIN_RELEASE_BRANCH", "TRAIN_TOKEN", "GITHUB_TOKEN", "GH_TOKEN", "TRAIN_IGNORE_REPOS"} {
+				t.Setenv(env, "")
+			}
+
+			initEnvs()
+		})
+
+		g.It("should read the config file without overrides", func() {
+			c, err := loadConfig("")
+			Expect(err).To(BeNil())
+			Expect(c.ReleaseBranch).To(Equal("file"))
+			Expect(c.Github.Token).To(Equal("file-token"))
+		})
+
+		g.It("should override a field from its TRAIN_ environment variable", func() {
+			t.Setenv("TRAIN_RELEASE_BRANCH", "env")
+
+			c, err := loadConfig("")
+			Expect(err).To(BeNil())
+			Expect(c.ReleaseBranch).To(Equal("env"))
+			Expect(c.Explicit("release_branch")).To(BeTrue())
+		})
+
+		g.It("should take the token from GH_TOKEN, GITHUB_TOKEN, then TRAIN_TOKEN, each winning over the last", func() {
+			t.Setenv("GH_TOKEN", "gh-token")
+
+			c, err := loadConfig("")
+			Expect(err).To(BeNil())
+			Expect(c.Github.Token).To(Equal("gh-token"))
+
+			t.Setenv("GITHUB_TOKEN", "github-token")
+
+			c, err = loadConfig("")
+			Expect(err).To(BeNil())
+			Expect(c.Github.Token).To(Equal("github-token"))
+
+			t.Setenv("TRAIN_TOKEN", "train-token")
+
+			c, err = loadConfig("")
+			Expect(err).To(BeNil())
+			Expect(c.Github.Token).To(Equal("train-token"))
+		})
+
+		g.It("should split a list from a comma separated environment variable", func() {
+			t.Setenv("TRAIN_IGNORE_REPOS", "sandbox, gomicro/scratch")
+
+			c, err := loadConfig("")
+			Expect(err).To(BeNil())
+			Expect(c.Github.Ignores.Repos).To(Equal([]string{"sandbox", "gomicro/scratch"}))
+		})
+
+		g.It("should prefer a flag over the environment", func() {
+			t.Setenv("TRAIN_RELEASE_BRANCH", "env")
+			t.Setenv("GITHUB_TOKEN", "github-token")
+			setFlag("release-branch", "flag")
+			setFlag("token", "flag-token")
+
+			c, err := loadConfig("")
+			Expect(err).To(BeNil())
+			Expect(c.ReleaseBranch).To(Equal("flag"))
+			Expect(c.Github.Token).To(Equal("flag-token"))
+		})
+	})
+}
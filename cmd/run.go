@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gomicro/crawl"
+	"github.com/gomicro/train/client"
+	"github.com/spf13/cobra"
+)
+
+var runTimeout time.Duration
+
+// addRunFlags registers the flags controlling how long a run may take.
+func addRunFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&runTimeout, "timeout", 0, "abort the run if it takes longer than this, such as 30m (default no limit)")
+}
+
+// runContext returns the context for a run. The first interrupt or terminate
+// signal asks the run to stop once the repo in flight is finished, a second
+// one cancels the context to abort right away, as does the run timing out.
+func runContext(notify io.Writer) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if runTimeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, runTimeout)
+
+		cancelParent := cancel
+		cancel = func() {
+			cancelTimeout()
+			cancelParent()
+		}
+	}
+
+	stop := make(chan struct{})
+	ctx = client.WithStop(ctx, stop)
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-sigs:
+		case <-ctx.Done():
+			return
+		}
+
+		fmt.Fprintln(notify, "\nStopping after the current repo, interrupt again to abort")
+		close(stop)
+
+		select {
+		case <-sigs:
+			fmt.Fprintln(notify, "\nAborting")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigs)
+		cancel()
+	}
+}
+
+// startProgress starts rendering progress bars. The returned func stops the
+// rendering and waits for the final frame, so that output written after it is
+// not mangled by the bars. It is safe to call more than once.
+func startProgress(ctx context.Context, out io.Writer) (*crawl.Progress, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	progress := crawl.New(ctx, out)
+	progress.SetOut(out)
+
+	done := make(chan struct{})
+	go func() {
+		progress.Listen()
+		close(done)
+	}()
+
+	var once sync.Once
+
+	return progress, func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gomicro/train/client"
+	"github.com/gomicro/train/journal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(NewRunsCmd(os.Stdout))
+}
+
+var (
+	// journalDir returns where run journals are kept
+	journalDir = journal.Dir
+
+	// resuming is the journal of the run being resumed, if any
+	resuming *journal.Journal
+)
+
+func NewRunsCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "Browse past create and release runs",
+		Long:  `Browse the journals of past create and release runs, which record what was done to each repo.`,
+	}
+
+	cmd.AddCommand(NewRunsListCmd(out))
+	cmd.AddCommand(NewRunsShowCmd(out))
+
+	cmd.SetOut(out)
+
+	return cmd
+}
+
+func NewRunsListCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List past runs",
+		Long:  `List past create and release runs, most recent first.`,
+		Args:  cobra.NoArgs,
+		RunE:  runsListRun(out),
+	}
+
+	cmd.SetOut(out)
+
+	return cmd
+}
+
+func NewRunsShowCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "show <run-id>",
+		Short:             "Show what a run did",
+		Long:              `Show what a create or release run did to each repo.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              runsShowRun(out),
+		ValidArgsFunction: runIDValidArgsFunc,
+	}
+
+	cmd.SetOut(out)
+
+	return cmd
+}
+
+func runsListRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		dir, err := journalDir()
+		if err != nil {
+			return fmt.Errorf("runs: %w", err)
+		}
+
+		runs, err := journal.List(dir)
+		if err != nil {
+			return fmt.Errorf("runs: %w", err)
+		}
+
+		if len(runs) == 0 {
+			fmt.Fprintln(out, "No runs recorded")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tACTION\tSELECTION\tSTATUS\tDONE\tSKIPPED\tFAILED\tSTARTED")
+
+		for _, r := range runs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
+				r.Header.ID,
+				r.Header.Action,
+				r.Header.Selection(),
+				r.Status(),
+				r.Count(client.ResultDone),
+				r.Count(client.ResultSkipped),
+				r.Count(client.ResultFailed),
+				r.Header.Started.Local().Format(time.RFC822),
+			)
+		}
+
+		return w.Flush()
+	}
+}
+
+func runsShowRun(out io.Writer) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		dir, err := journalDir()
+		if err != nil {
+			return fmt.Errorf("runs: %w", err)
+		}
+
+		run, err := journal.Read(dir, args[0])
+		if err != nil {
+			return fmt.Errorf("runs: %w", err)
+		}
+
+		fmt.Fprintf(out, "Run: %s\n", run.Header.ID)
+		fmt.Fprintf(out, "Action: %s\n", run.Header.Action)
+		fmt.Fprintf(out, "Selection: %s\n", run.Header.Selection())
+		fmt.Fprintf(out, "Started: %s\n", run.Header.Started.Local().Format(time.RFC822))
+		fmt.Fprintf(out, "Status: %s\n", run.Status())
+
+		if run.Resumes > 0 {
+			fmt.Fprintf(out, "Resumed: %d times\n", run.Resumes)
+		}
+
+		if run.Error != "" {
+			fmt.Fprintf(out, "Error: %s\n", run.Error)
+		}
+
+		results := run.Latest()
+		if len(results) == 0 {
+			return nil
+		}
+
+		fmt.Fprintln(out)
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, r := range results {
+			detail := r.URL
+			if r.Reason != "" {
+				detail = fmt.Sprintf("%s %s", detail, r.Reason)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\n", r.Status, r.Repo, detail)
+		}
+
+		return w.Flush()
+	}
+}
+
+// startJournal opens the journal recording a live run, or continues the one
+// being resumed. Dry runs make no changes, and are not recorded.
+func startJournal(action string, sel *selection) (*journal.Journal, error) {
+	if dryRun {
+		return nil, nil
+	}
+
+	if resuming != nil {
+		return resuming, nil
+	}
+
+	dir, err := journalDir()
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := runSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	return journal.New(dir, &journal.Header{
+		Action:         action,
+		Args:           sel.args,
+		Team:           sel.team,
+		TeamPermission: sel.teamPermission,
+		Query:          sel.query,
+		Profile:        viper.GetString("profile"),
+		Settings:       settings,
+	})
+}
+
+// runSettings returns the effective settings of the config the client was set
+// up with, or nil if there is none.
+func runSettings() (*journal.Settings, error) {
+	if conf == nil {
+		return nil, nil
+	}
+
+	path := viper.GetString("config")
+	if path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("config path: %w", err)
+		}
+
+		path = abs
+	}
+
+	host := conf.Github.APIURL
+	if host == "" {
+		host = "github.com"
+	}
+
+	return &journal.Settings{
+		Config:        path,
+		Host:          host,
+		ReleaseBranch: conf.ReleaseBranch,
+		MergeMethod:   conf.MergeMethod,
+	}, nil
+}
+
+// finishJournal records the end of a run, pointing at how to resume it if it
+// failed.
+func finishJournal(j *journal.Journal, runErr error) error {
+	if j == nil {
+		return runErr
+	}
+
+	err := j.Finish(runErr)
+	if runErr != nil {
+		return fmt.Errorf("%w, resume with `train resume %s`", runErr, j.Header().ID)
+	}
+
+	return err
+}
+
+func runIDValidArgsFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	dir, err := journalDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	runs, err := journal.List(dir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var ids []string
+	for _, r := range runs {
+		ids = append(ids, fmt.Sprintf("%s\t%s %s, %s", r.Header.ID, r.Header.Action, r.Header.Selection(), r.Status()))
+	}
+
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/penname"
+	"github.com/gomicro/train/client"
+	"github.com/gomicro/train/client/clienttest"
+	"github.com/gomicro/train/config"
+	"github.com/gomicro/train/journal"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestRuns(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	repos := []*github.Repository{
+		{
+			Name:          github.String("steward"),
+			Owner:         &github.User{Login: github.String("gomicro")},
+			DefaultBranch: github.String("master"),
+		},
+		{
+			Name:          github.String("penname"),
+			Owner:         &github.User{Login: github.String("gomicro")},
+			DefaultBranch: github.String("master"),
+		},
+	}
+
+	g.Describe("Runs", func() {
+		var dir string
+
+		g.BeforeEach(func() {
+			dir = t.TempDir()
+			journalDir = func() (string, error) { return dir, nil }
+		})
+
+		g.AfterEach(func() {
+			journalDir = journal.Dir
+		})
+
+		g.It("should record a create run", func() {
+			cmd := NewCreateCmd(penname.New())
+			cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+				clt = clienttest.New(&clienttest.Config{
+					BaseBranchName: "release",
+					Repos:          repos,
+				})
+
+				dryRun = viper.GetBool("dryRun")
+			}
+
+			cmd.SetArgs([]string{"gomicro", "--yes"})
+			Expect(cmd.Execute()).To(BeNil())
+
+			runs, err := journal.List(dir)
+			Expect(err).To(BeNil())
+			Expect(runs).To(HaveLen(1))
+			Expect(runs[0].Status()).To(Equal(journal.StatusComplete))
+			Expect(runs[0].Count(client.ResultDone)).To(Equal(2))
+
+			w := penname.New()
+			list := NewRunsListCmd(w)
+			list.SetArgs([]string{})
+			Expect(list.Execute()).To(BeNil())
+			Expect(string(w.Written())).To(ContainSubstring(runs[0].Header.ID))
+		})
+
+		g.It("should report what an interrupted run finished", func() {
+			w := penname.New()
+			cmd := NewCreateCmd(w)
+			cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+				clt = clienttest.New(&clienttest.Config{
+					BaseBranchName: "release",
+					Repos:          repos,
+					StopAfter:      1,
+				})
+
+				dryRun = viper.GetBool("dryRun")
+			}
+
+			cmd.SetArgs([]string{"gomicro", "--yes"})
+			err := cmd.Execute()
+			Expect(errors.Is(err, client.ErrInterrupted)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("train resume"))
+
+			cmdOut := string(w.Written())
+			Expect(cmdOut).To(ContainSubstring("Release PRs Created before the run stopped:"))
+			Expect(cmdOut).To(ContainSubstring("gomicro/steward"))
+			Expect(cmdOut).NotTo(ContainSubstring("gomicro/penname"))
+
+			runs, err := journal.List(dir)
+			Expect(err).To(BeNil())
+			Expect(runs).To(HaveLen(1))
+			Expect(runs[0].Status()).To(Equal(journal.StatusFailed))
+			Expect(runs[0].Count(client.ResultDone)).To(Equal(1))
+		})
+
+		g.It("should resume a run, skipping the repos it finished", func() {
+			j, err := journal.New(dir, &journal.Header{
+				Action: client.ActionCreate,
+				Args:   []string{"gomicro"},
+			})
+			Expect(err).To(BeNil())
+			Expect(j.Record(client.Result{Repo: "gomicro/steward", Status: client.ResultDone})).To(BeNil())
+			Expect(j.Finish(fmt.Errorf("interrupted"))).To(BeNil())
+
+			w := penname.New()
+			cmd := NewResumeCmd(w, func(cmd *cobra.Command, args []string) {
+				Expect(args).To(Equal([]string{"gomicro"}))
+
+				clt = clienttest.New(&clienttest.Config{
+					BaseBranchName: "release",
+					Repos:          repos,
+				})
+			})
+
+			cmd.SetArgs([]string{j.Header().ID, "--yes"})
+			Expect(cmd.Execute()).To(BeNil())
+			Expect(string(w.Written())).To(ContainSubstring("gomicro/penname"))
+			Expect(string(w.Written())).NotTo(ContainSubstring("gomicro/steward"))
+
+			run, err := journal.Read(dir, j.Header().ID)
+			Expect(err).To(BeNil())
+			Expect(run.Status()).To(Equal(journal.StatusComplete))
+			Expect(run.Resumes).To(Equal(1))
+			Expect(run.Count(client.ResultDone)).To(Equal(2))
+		})
+
+		g.It("should resume a team run without changing the repo flags", func() {
+			j, err := journal.New(dir, &journal.Header{
+				Action:         client.ActionCreate,
+				Team:           "gomicro/devs",
+				TeamPermission: "push",
+			})
+			Expect(err).To(BeNil())
+			Expect(j.Finish(fmt.Errorf("interrupted"))).To(BeNil())
+
+			w := penname.New()
+			cmd := NewResumeCmd(w, func(cmd *cobra.Command, args []string) {
+				Expect(args).To(Equal([]string{"gomicro/devs"}))
+
+				clt = clienttest.New(&clienttest.Config{
+					BaseBranchName: "release",
+					Repos:          repos,
+				})
+			})
+
+			cmd.SetArgs([]string{j.Header().ID, "--yes"})
+			Expect(cmd.Execute()).To(BeNil())
+			Expect(string(w.Written())).To(ContainSubstring("Team: gomicro/devs"))
+
+			Expect(team).To(BeEmpty())
+			Expect(teamPermission).To(BeEmpty())
+			Expect(query).To(BeEmpty())
+		})
+
+		g.It("should record the settings a run was started with", func() {
+			cmd := NewCreateCmd(penname.New())
+			cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+				clt = clienttest.New(&clienttest.Config{
+					BaseBranchName: "production",
+					Repos:          repos,
+				})
+
+				conf = config.Default()
+				conf.ReleaseBranch = "production"
+				conf.MergeMethod = "squash"
+
+				dryRun = viper.GetBool("dryRun")
+			}
+			defer func() { conf = nil }()
+
+			cmd.SetArgs([]string{"gomicro", "--yes"})
+			Expect(cmd.Execute()).To(BeNil())
+
+			runs, err := journal.List(dir)
+			Expect(err).To(BeNil())
+			Expect(runs).To(HaveLen(1))
+			Expect(runs[0].Header.Settings).To(Equal(&journal.Settings{
+				Host:          "github.com",
+				ReleaseBranch: "production",
+				MergeMethod:   "squash",
+			}))
+		})
+
+		g.It("should refuse to resume a run with different settings", func() {
+			j, err := journal.New(dir, &journal.Header{
+				Action: client.ActionCreate,
+				Args:   []string{"gomicro"},
+				Settings: &journal.Settings{
+					Host:          "github.com",
+					ReleaseBranch: "release",
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(j.Finish(fmt.Errorf("interrupted"))).To(BeNil())
+
+			cmd := NewResumeCmd(penname.New(), func(cmd *cobra.Command, args []string) {
+				clt = clienttest.New(&clienttest.Config{
+					BaseBranchName: "production",
+					Repos:          repos,
+				})
+
+				conf = config.Default()
+				conf.ReleaseBranch = "production"
+			})
+			defer func() { conf = nil }()
+
+			cmd.SetArgs([]string{j.Header().ID, "--yes"})
+			err = cmd.Execute()
+			Expect(errors.Is(err, journal.ErrSettingsChanged)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(`release branch was "release", now "production"`))
+
+			run, err := journal.Read(dir, j.Header().ID)
+			Expect(err).To(BeNil())
+			Expect(run.Resumes).To(Equal(0))
+		})
+
+		g.It("should refuse to resume a run as a dry run", func() {
+			j, err := journal.New(dir, &journal.Header{Action: client.ActionCreate, Args: []string{"gomicro"}})
+			Expect(err).To(BeNil())
+			Expect(j.Finish(fmt.Errorf("interrupted"))).To(BeNil())
+
+			viper.Set("dryRun", true)
+			defer viper.Set("dryRun", false)
+
+			setup := false
+			cmd := NewResumeCmd(penname.New(), func(*cobra.Command, []string) { setup = true })
+			cmd.SetArgs([]string{j.Header().ID, "--yes"})
+			Expect(cmd.Execute()).To(MatchError(ErrResumeDryRun))
+			Expect(setup).To(BeFalse())
+
+			run, err := journal.Read(dir, j.Header().ID)
+			Expect(err).To(BeNil())
+			Expect(run.Resumes).To(Equal(0))
+		})
+
+		g.It("should refuse to resume a complete run", func() {
+			j, err := journal.New(dir, &journal.Header{Action: client.ActionCreate, Args: []string{"gomicro"}})
+			Expect(err).To(BeNil())
+			Expect(j.Finish(nil)).To(BeNil())
+
+			cmd := NewResumeCmd(penname.New(), func(*cobra.Command, []string) {})
+			cmd.SetArgs([]string{j.Header().ID})
+			Expect(cmd.Execute()).To(MatchError(journal.ErrComplete))
+		})
+	})
+}
@@ -0,0 +1,12 @@
+package config
+
+import "testing"
+
+// SetHomeDir points the current user's home directory at the one given for
+// the rest of the test.
+func SetHomeDir(t *testing.T, dir string) {
+	saved := homeDir
+	homeDir = func() (string, error) { return dir, nil }
+
+	t.Cleanup(func() { homeDir = saved })
+}
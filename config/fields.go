@@ -0,0 +1,362 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownField = errors.New("unknown config field")
+	ErrInvalidValue = errors.New("invalid value")
+	ErrNotList      = errors.New("not a list field")
+)
+
+// Kind represents the type of value a config field holds
+type Kind int
+
+const (
+	// KindString is a field holding a single string
+	KindString Kind = iota
+	// KindInt is a field holding a single integer
+	KindInt
+	// KindList is a field holding a list of strings
+	KindList
+)
+
+// Field represents a single setting within the config, the path it is
+// addressed by, and the environment variables and flag that may override it.
+type Field struct {
+	Path   string
+	Usage  string
+	Kind   Kind
+	Flag   string
+	Env    []string
+	Values []string
+	Secret bool
+
+	get func(*Config) interface{}
+	set func(*Config, interface{})
+}
+
+// Fields is the collection of every setting within the config.
+var Fields = []*Field{
+	{
+		Path:  "release_branch",
+		Usage: "the base branch name to use for creating the release PRs",
+		Kind:  KindString,
+		Flag:  "release-branch",
+		Env:   []string{"TRAIN_RELEASE_BRANCH"},
+		get:   func(c *Config) interface{} { return c.ReleaseBranch },
+		set:   func(c *Config, v interface{}) { c.ReleaseBranch = v.(string) },
+	},
+	{
+		Path:  "merge_method",
+		Usage: "the method used to merge release PRs (merge, squash, or rebase)",
+		Kind:  KindString,
+		Flag:  "merge-method",
+		Env:   []string{"TRAIN_MERGE_METHOD"},
+		Values: []string{
+			"merge",
+			"squash",
+			"rebase",
+		},
+		get: func(c *Config) interface{} { return c.MergeMethod },
+		set: func(c *Config, v interface{}) { c.MergeMethod = v.(string) },
+	},
+	{
+		Path:  "audit_log",
+		Usage: "the file to append a record of each change made on github to, or - for stdout (default ~/.train/audit.jsonl)",
+		Kind:  KindString,
+		Flag:  "audit-log",
+		Env:   []string{"TRAIN_AUDIT_LOG"},
+		get:   func(c *Config) interface{} { return c.AuditLog },
+		set:   func(c *Config, v interface{}) { c.AuditLog = v.(string) },
+	},
+	{
+		Path:   "github.com.token",
+		Usage:  "the token used to authenticate with github",
+		Kind:   KindString,
+		Flag:   "token",
+		Env:    []string{"TRAIN_TOKEN", "GITHUB_TOKEN", "GH_TOKEN"},
+		Secret: true,
+		get:    func(c *Config) interface{} { return c.host().Token },
+		set:    func(c *Config, v interface{}) { c.host().Token = v.(string) },
+	},
+	{
+		Path:  "github.com.app.id",
+		Usage: "the id of the github app to authenticate as",
+		Kind:  KindInt,
+		Flag:  "app-id",
+		Env:   []string{"TRAIN_APP_ID"},
+		get:   func(c *Config) interface{} { return int(c.app().ID) },
+		set:   func(c *Config, v interface{}) { c.app().ID = int64(v.(int)) },
+	},
+	{
+		Path:  "github.com.app.installation_id",
+		Usage: "the github app installation to use for requests not scoped to an org or user",
+		Kind:  KindInt,
+		Flag:  "app-installation-id",
+		Env:   []string{"TRAIN_APP_INSTALLATION_ID"},
+		get:   func(c *Config) interface{} { return int(c.app().InstallationID) },
+		set:   func(c *Config, v interface{}) { c.app().InstallationID = int64(v.(int)) },
+	},
+	{
+		Path:  "github.com.app.private_key_file",
+		Usage: "the path to the private key of the github app",
+		Kind:  KindString,
+		Flag:  "app-private-key-file",
+		Env:   []string{"TRAIN_APP_PRIVATE_KEY_FILE"},
+		get:   func(c *Config) interface{} { return c.app().PrivateKeyFile },
+		set:   func(c *Config, v interface{}) { c.app().PrivateKeyFile = v.(string) },
+	},
+	{
+		Path:  "github.com.limits.request_per_second",
+		Usage: "the number of requests per second allowed against github",
+		Kind:  KindInt,
+		Flag:  "requests-per-second",
+		Env:   []string{"TRAIN_REQUESTS_PER_SECOND"},
+		get:   func(c *Config) interface{} { return c.limits().RequestsPerSecond },
+		set:   func(c *Config, v interface{}) { c.limits().RequestsPerSecond = v.(int) },
+	},
+	{
+		Path:  "github.com.limits.burst",
+		Usage: "the number of requests allowed to burst against github",
+		Kind:  KindInt,
+		Flag:  "burst",
+		Env:   []string{"TRAIN_BURST"},
+		get:   func(c *Config) interface{} { return c.limits().Burst },
+		set:   func(c *Config, v interface{}) { c.limits().Burst = v.(int) },
+	},
+	{
+		Path:  "github.com.limits.retries",
+		Usage: "the number of times to retry a request after a transient failure",
+		Kind:  KindInt,
+		Flag:  "retries",
+		Env:   []string{"TRAIN_RETRIES"},
+		get:   func(c *Config) interface{} { return c.limits().Retries },
+		set:   func(c *Config, v interface{}) { c.limits().Retries = v.(int) },
+	},
+	{
+		Path:  "github.com.ignores.repos",
+		Usage: "repos to ignore, by name or owner/name",
+		Kind:  KindList,
+		Flag:  "ignore-repos",
+		Env:   []string{"TRAIN_IGNORE_REPOS"},
+		get:   func(c *Config) interface{} { return c.ignores().Repos },
+		set:   func(c *Config, v interface{}) { c.ignores().Repos = v.([]string) },
+	},
+	{
+		Path:  "github.com.ignores.topics",
+		Usage: "repo topics to ignore",
+		Kind:  KindList,
+		Flag:  "ignore-topics",
+		Env:   []string{"TRAIN_IGNORE_TOPICS"},
+		get:   func(c *Config) interface{} { return c.ignores().Topics },
+		set:   func(c *Config, v interface{}) { c.ignores().Topics = v.([]string) },
+	},
+	{
+		Path:  "github.com.ensures.repos",
+		Usage: "repos to ensure, by name or owner/name",
+		Kind:  KindList,
+		Flag:  "ensure-repos",
+		Env:   []string{"TRAIN_ENSURE_REPOS"},
+		get:   func(c *Config) interface{} { return c.ensures().Repos },
+		set:   func(c *Config, v interface{}) { c.ensures().Repos = v.([]string) },
+	},
+	{
+		Path:  "github.com.ensures.topics",
+		Usage: "repo topics to ensure",
+		Kind:  KindList,
+		Flag:  "ensure-topics",
+		Env:   []string{"TRAIN_ENSURE_TOPICS"},
+		get:   func(c *Config) interface{} { return c.ensures().Topics },
+		set:   func(c *Config, v interface{}) { c.ensures().Topics = v.([]string) },
+	},
+}
+
+// LookupField returns the field addressed by the path given.
+func LookupField(path string) (*Field, error) {
+	for _, f := range Fields {
+		if strings.EqualFold(f.Path, path) {
+			return f, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownField, path)
+}
+
+// Get returns the value of the field within the config, formatted as a
+// string. Lists are joined with commas.
+func (f *Field) Get(c *Config) string {
+	switch v := f.get(c).(type) {
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Set parses the value given according to the kind of the field and sets it
+// within the config. Lists are split on commas. It returns an error if the
+// value is not valid for the field.
+func (f *Field) Set(c *Config, value string) error {
+	switch f.Kind {
+	case KindInt:
+		i, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%w: %s: expected an integer: %s", ErrInvalidValue, f.Path, value)
+		}
+
+		f.set(c, i)
+	case KindList:
+		f.set(c, splitList(value))
+	default:
+		if value != "" && len(f.Values) > 0 && !contains(f.Values, value) {
+			return fmt.Errorf("%w: %s: expected one of %s: %s", ErrInvalidValue, f.Path, strings.Join(f.Values, ", "), value)
+		}
+
+		f.set(c, value)
+	}
+
+	return nil
+}
+
+// Override sets the field within the config as Set does, marking it as
+// explicitly set so that org and repo config files leave it alone.
+func (f *Field) Override(c *Config, value string) error {
+	err := f.Set(c, value)
+	if err != nil {
+		return err
+	}
+
+	c.markExplicit(f.Path)
+
+	return nil
+}
+
+// Unset returns the field within the config to its default value.
+func (f *Field) Unset(c *Config) {
+	f.set(c, f.get(Default()))
+}
+
+// Add appends the values given to a list field within the config, skipping
+// any already present.
+func (f *Field) Add(c *Config, values ...string) error {
+	if f.Kind != KindList {
+		return fmt.Errorf("%w: %s", ErrNotList, f.Path)
+	}
+
+	list := append([]string{}, f.get(c).([]string)...)
+	for _, v := range values {
+		if !contains(list, v) {
+			list = append(list, v)
+		}
+	}
+
+	f.set(c, list)
+
+	return nil
+}
+
+// Remove removes the values given from a list field within the config.
+func (f *Field) Remove(c *Config, values ...string) error {
+	if f.Kind != KindList {
+		return fmt.Errorf("%w: %s", ErrNotList, f.Path)
+	}
+
+	list := []string{}
+	for _, v := range f.get(c).([]string) {
+		if !contains(values, v) {
+			list = append(list, v)
+		}
+	}
+
+	f.set(c, list)
+
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitList(value string) []string {
+	list := []string{}
+
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
+
+// Explicit reports whether the field at the path given was set by a profile,
+// a flag, or an environment variable, rather than by a config file or the
+// defaults.
+func (c *Config) Explicit(path string) bool {
+	_, ok := c.explicit[strings.ToLower(path)]
+	return ok
+}
+
+func (c *Config) markExplicit(path string) {
+	if c.explicit == nil {
+		c.explicit = map[string]struct{}{}
+	}
+
+	c.explicit[strings.ToLower(path)] = struct{}{}
+}
+
+func (c *Config) host() *GithubHost {
+	if c.Github == nil {
+		c.Github = &GithubHost{}
+	}
+
+	return c.Github
+}
+
+func (c *Config) limits() *Limits {
+	h := c.host()
+	if h.Limits == nil {
+		h.Limits = &Limits{}
+	}
+
+	return h.Limits
+}
+
+func (c *Config) ignores() *GithubIgnores {
+	h := c.host()
+	if h.Ignores == nil {
+		h.Ignores = &GithubIgnores{}
+	}
+
+	return h.Ignores
+}
+
+func (c *Config) ensures() *GithubEnsures {
+	h := c.host()
+	if h.Ensures == nil {
+		h.Ensures = &GithubEnsures{}
+	}
+
+	return h.Ensures
+}
+
+func (c *Config) app() *GithubApp {
+	h := c.host()
+	if h.App == nil {
+		h.App = &GithubApp{}
+	}
+
+	return h.App
+}
@@ -10,37 +10,136 @@ import (
 )
 
 const (
-	confDir  = "/.train"
-	confFile = "/config"
+	confDir     = "/.train"
+	confFile    = "/config"
+	projectFile = ".train.yaml"
+
+	// RepoFile is the path within a repo of its train config file
+	RepoFile = ".train.yml"
+
+	// OrgRepo is the name of the repo holding an org's train config file
+	OrgRepo = ".github"
+	// OrgFile is the path within the org repo of the org's train config file
+	OrgFile = "train.yml"
 )
 
-var DefaultConfig = Config{
-	ReleaseBranch: "release",
-	Github: &GithubHost{
-		Limits: &Limits{
-			RequestsPerSecond: 10,
-			Burst:             25,
+// Default returns a new config populated with the default values train uses
+// when no config file overrides them.
+func Default() *Config {
+	return &Config{
+		Version:       CurrentVersion,
+		ReleaseBranch: "release",
+		Github: &GithubHost{
+			Limits: &Limits{
+				RequestsPerSecond: 10,
+				Burst:             25,
+				Retries:           3,
+			},
+			Ignores: &GithubIgnores{},
 		},
-		Ignores: &GithubIgnores{},
-	},
+	}
 }
 
 // Config represents the config file for train
 type Config struct {
+	Version       int         `yaml:"version"`
 	ReleaseBranch string      `yaml:"release_branch"`
+	MergeMethod   string      `yaml:"merge_method,omitempty"`
+	AuditLog      string      `yaml:"audit_log,omitempty"`
 	Github        *GithubHost `yaml:"github.com"`
+
+	Profiles map[string]*Profile `yaml:"profiles,omitempty"`
+
+	// explicit holds the paths of the fields set by a profile or an override
+	explicit map[string]struct{}
 }
 
 // Limits represents a limits override for the client
 type Limits struct {
 	RequestsPerSecond int `yaml:"request_per_second"`
 	Burst             int `yaml:"burst"`
+	// Retries is how many times an idempotent request is retried after a
+	// transient failure
+	Retries int `yaml:"retries"`
+
+	// keys holds the keys present when read from a file, so that a zero
+	// value set on purpose can be told apart from one left out
+	keys map[string]struct{}
+}
+
+// UnmarshalYAML reads the limits, noting which keys were present.
+func (l *Limits) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Limits
+	err := unmarshal((*plain)(l))
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]interface{}
+	err = unmarshal(&raw)
+	if err != nil {
+		return err
+	}
+
+	l.keys = map[string]struct{}{}
+	for k := range raw {
+		l.keys[k] = struct{}{}
+	}
+
+	return nil
+}
+
+// isSet reports whether the key given was present when the limits were read.
+// Limits not read from a file have every key set.
+func (l *Limits) isSet(key string) bool {
+	if l.keys == nil {
+		return true
+	}
+
+	_, ok := l.keys[key]
+	return ok
 }
 
 // New takes a token string and creates the most basic config capable of being
 // written.
 func New(tkn string) *Config {
-	return &Config{Github: &GithubHost{Token: tkn}}
+	return &Config{Version: CurrentVersion, Github: &GithubHost{Token: tkn}}
+}
+
+// homeDir returns the home directory of the current user, preferring $HOME
+// over the user database, and is swapped out in tests.
+var homeDir = func() (string, error) {
+	home, err := os.UserHomeDir()
+	if err == nil {
+		return home, nil
+	}
+
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+
+	return usr.HomeDir, nil
+}
+
+// Dir returns the directory train keeps its files in for the current user.
+func Dir() (string, error) {
+	home, err := homeDir()
+	if err != nil {
+		return "", fmt.Errorf("config: get home directory: %v", err.Error())
+	}
+
+	return filepath.Join(home, confDir), nil
+}
+
+// FilePath returns the location of the config file for the current user.
+func FilePath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, confFile), nil
 }
 
 // WriteFile writes the file to the defined location for the current user, and
@@ -51,12 +150,12 @@ func (c *Config) WriteFile() error {
 		return fmt.Errorf("config: marshal: %v", err.Error())
 	}
 
-	usr, err := user.Current()
+	path, err := FilePath()
 	if err != nil {
-		return fmt.Errorf("config: get home directory: %v", err.Error())
+		return err
 	}
 
-	err = os.WriteFile(filepath.Join(usr.HomeDir, confDir, confFile), b, 0600)
+	err = os.WriteFile(path, b, 0600)
 	if err != nil {
 		return fmt.Errorf("config: write file: %v", err.Error())
 	}
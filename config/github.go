@@ -1,11 +1,15 @@
 package config
 
-// GithubHost represents a single host for which train has a configuration
+// GithubHost represents a single host for which train has a configuration.
+// The API URL is only needed for GitHub Enterprise hosts.
 type GithubHost struct {
-	Token   string         `yaml:"token"`
-	Ensures *GithubEnsures `yaml:"ensures"`
-	Ignores *GithubIgnores `yaml:"ignores"`
-	Limits  *Limits        `yaml:"limits"`
+	APIURL     string         `yaml:"api_url,omitempty"`
+	Token      string         `yaml:"token"`
+	Credential *Credential    `yaml:"credential,omitempty"`
+	App        *GithubApp     `yaml:"app,omitempty"`
+	Ensures    *GithubEnsures `yaml:"ensures"`
+	Ignores    *GithubIgnores `yaml:"ignores"`
+	Limits     *Limits        `yaml:"limits"`
 }
 
 type GithubEnsures struct {
@@ -17,3 +21,25 @@ type GithubIgnores struct {
 	Repos  []string `yaml:"repos"`
 	Topics []string `yaml:"topics"`
 }
+
+// Credential represents a reference to a token kept outside of the config
+// file, in the named store under the key given.
+type Credential struct {
+	Store  string `yaml:"store"`
+	Key    string `yaml:"key,omitempty"`
+	Helper string `yaml:"helper,omitempty"`
+}
+
+// GithubApp represents the GitHub App train authenticates as in place of a
+// user's token. The installation id is only needed for requests not scoped to
+// an org or user, such as searches.
+type GithubApp struct {
+	ID             int64  `yaml:"id,omitempty"`
+	InstallationID int64  `yaml:"installation_id,omitempty"`
+	PrivateKeyFile string `yaml:"private_key_file,omitempty"`
+}
+
+// IsSet returns whether an app has been configured.
+func (a *GithubApp) IsSet() bool {
+	return a != nil && (a.ID != 0 || a.PrivateKeyFile != "")
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// CurrentVersion is the version of the config file format this build of train
+// reads and writes.
+const CurrentVersion = 1
+
+var ErrUnsupportedVersion = errors.New("unsupported config version")
+
+// migration represents a single step upgrading a config file from one version
+// of the format to the next. Apply returns a description of each change made.
+type migration struct {
+	from  int
+	apply func(yaml.MapSlice) (yaml.MapSlice, []string)
+}
+
+// migrations is the ordered pipeline of steps, where each step upgrades a
+// config file from its version to the version following it.
+var migrations = []migration{
+	{
+		from: 0,
+		apply: func(doc yaml.MapSlice) (yaml.MapSlice, []string) {
+			return doc, nil
+		},
+	},
+}
+
+// MigrationReport represents the outcome of migrating a config file.
+type MigrationReport struct {
+	Path        string
+	FromVersion int
+	ToVersion   int
+	Changes     []string
+	Backup      string
+}
+
+// Changed returns whether the migration changed the file, which includes
+// stamping the current version on a file from an older one.
+func (r *MigrationReport) Changed() bool {
+	return len(r.Changes) > 0
+}
+
+func (r *MigrationReport) String() string {
+	if !r.Changed() {
+		return fmt.Sprintf("%s is up to date at version %d", r.Path, r.ToVersion)
+	}
+
+	s := fmt.Sprintf("%s migrated from version %d to %d", r.Path, r.FromVersion, r.ToVersion)
+	for _, c := range r.Changes {
+		s += fmt.Sprintf("\n  - %s", c)
+	}
+
+	if r.Backup != "" {
+		s += fmt.Sprintf("\nbackup kept at %s", r.Backup)
+	}
+
+	return s
+}
+
+// Migrate upgrades the raw contents of a config file to the current version
+// of the format. It returns the upgraded contents and a report of what
+// changed, or an error if the file is from a newer version of train.
+func Migrate(b []byte) ([]byte, *MigrationReport, error) {
+	var doc yaml.MapSlice
+	err := yaml.Unmarshal(b, &doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unmarshal: %v", err.Error())
+	}
+
+	version, err := docVersion(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &MigrationReport{
+		FromVersion: version,
+		ToVersion:   version,
+	}
+
+	if version > CurrentVersion {
+		return nil, nil, fmt.Errorf("%w: file is version %d, train supports up to %d", ErrUnsupportedVersion, version, CurrentVersion)
+	}
+
+	if version == CurrentVersion {
+		return b, report, nil
+	}
+
+	for _, m := range migrations {
+		if m.from < version {
+			continue
+		}
+
+		var changes []string
+		doc, changes = m.apply(doc)
+		report.Changes = append(report.Changes, changes...)
+
+		report.ToVersion = m.from + 1
+	}
+
+	doc = setVersion(doc, report.ToVersion)
+	report.Changes = append(report.Changes, fmt.Sprintf("set version to %d", report.ToVersion))
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal: %v", err.Error())
+	}
+
+	return out, report, nil
+}
+
+// MigrateFile upgrades the config file at the path given to the current
+// version of the format. Unless it is a dry run, the original file is kept as
+// a backup alongside the upgraded one. It returns a report of what changed.
+func MigrateFile(path string, dryRun bool) (*MigrationReport, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: migrate: read file: %v", err.Error())
+	}
+
+	out, report, err := Migrate(b)
+	if err != nil {
+		return nil, fmt.Errorf("config: migrate: %s: %w", path, err)
+	}
+
+	report.Path = path
+
+	if !report.Changed() || dryRun {
+		return report, nil
+	}
+
+	report.Backup = fmt.Sprintf("%s.v%d.bak", path, report.FromVersion)
+
+	err = os.WriteFile(report.Backup, b, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("config: migrate: write backup: %v", err.Error())
+	}
+
+	err = os.WriteFile(path, out, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("config: migrate: write file: %v", err.Error())
+	}
+
+	return report, nil
+}
+
+func docVersion(doc yaml.MapSlice) (int, error) {
+	for _, item := range doc {
+		if item.Key != "version" {
+			continue
+		}
+
+		v, ok := item.Value.(int)
+		if !ok {
+			return 0, fmt.Errorf("%w: version must be an integer: got %v", ErrUnsupportedVersion, item.Value)
+		}
+
+		return v, nil
+	}
+
+	return 0, nil
+}
+
+func setVersion(doc yaml.MapSlice, version int) yaml.MapSlice {
+	for i := range doc {
+		if doc[i].Key == "version" {
+			doc[i].Value = version
+			return doc
+		}
+	}
+
+	return append(yaml.MapSlice{{Key: "version", Value: version}}, doc...)
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	"gopkg.in/yaml.v2"
+)
+
+func TestMigrations(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("MigrateFile", func() {
+		var saved []migration
+
+		g.BeforeEach(func() {
+			saved = migrations
+			migrations = []migration{
+				{
+					from: 0,
+					apply: func(doc yaml.MapSlice) (yaml.MapSlice, []string) {
+						for i := range doc {
+							if doc[i].Key == "branch" {
+								doc[i].Key = "release_branch"
+								return doc, []string{"renamed branch to release_branch"}
+							}
+						}
+
+						return doc, nil
+					},
+				},
+			}
+		})
+
+		g.AfterEach(func() {
+			migrations = saved
+		})
+
+		g.It("should rewrite a file a migration changes, keeping a backup", func() {
+			path := filepath.Join(t.TempDir(), "config")
+			original := "branch: release\n"
+			g.Assert(os.WriteFile(path, []byte(original), 0600)).IsNil()
+
+			report, err := MigrateFile(path, false)
+			g.Assert(err).IsNil()
+			g.Assert(report.Changed()).IsTrue()
+			g.Assert(report.Changes).Equal([]string{"renamed branch to release_branch", "set version to 1"})
+
+			b, err := os.ReadFile(report.Backup)
+			g.Assert(err).IsNil()
+			g.Assert(string(b)).Equal(original)
+
+			b, err = os.ReadFile(path)
+			g.Assert(err).IsNil()
+			g.Assert(string(b)).Equal("version: 1\nrelease_branch: release\n")
+		})
+
+		g.It("should not touch the file on a dry run", func() {
+			path := filepath.Join(t.TempDir(), "config")
+			original := "branch: release\n"
+			g.Assert(os.WriteFile(path, []byte(original), 0600)).IsNil()
+
+			report, err := MigrateFile(path, true)
+			g.Assert(err).IsNil()
+			g.Assert(report.Changed()).IsTrue()
+			g.Assert(report.Backup).Equal("")
+
+			b, err := os.ReadFile(path)
+			g.Assert(err).IsNil()
+			g.Assert(string(b)).Equal(original)
+		})
+	})
+}
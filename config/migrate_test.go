@@ -0,0 +1,92 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/train/config"
+	. "github.com/onsi/gomega"
+)
+
+func TestMigrate(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("MigrateFile", func() {
+		var path string
+
+		write := func(content string) {
+			path = filepath.Join(t.TempDir(), "config")
+			Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+		}
+
+		g.It("should stamp the version on a file needing no other changes, keeping a backup", func() {
+			content := "release_branch: release\ngithub.com:\n  token: abc\n"
+			write(content)
+
+			report, err := config.MigrateFile(path, false)
+			Expect(err).To(BeNil())
+			Expect(report.Changed()).To(BeTrue())
+			Expect(report.FromVersion).To(Equal(0))
+			Expect(report.ToVersion).To(Equal(config.CurrentVersion))
+			Expect(report.Changes).To(Equal([]string{"set version to 1"}))
+			Expect(report.Backup).To(Equal(path + ".v0.bak"))
+
+			b, err := os.ReadFile(report.Backup)
+			Expect(err).To(BeNil())
+			Expect(string(b)).To(Equal(content))
+
+			b, err = os.ReadFile(path)
+			Expect(err).To(BeNil())
+			Expect(string(b)).To(Equal("version: 1\n" + content))
+		})
+
+		g.It("should leave an old file as it is when only reading it", func() {
+			home := t.TempDir()
+			config.SetHomeDir(t, home)
+
+			path = filepath.Join(home, ".train", "config")
+			content := "release_branch: main\n"
+			Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+			Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+
+			c, err := config.ParseFromFile()
+			Expect(err).To(BeNil())
+			Expect(c.Version).To(Equal(config.CurrentVersion))
+			Expect(c.ReleaseBranch).To(Equal("main"))
+
+			b, err := os.ReadFile(path)
+			Expect(err).To(BeNil())
+			Expect(string(b)).To(Equal(content))
+
+			matches, err := filepath.Glob(path + ".*.bak")
+			Expect(err).To(BeNil())
+			Expect(matches).To(BeEmpty())
+		})
+
+		g.It("should report a current file as up to date", func() {
+			write("version: 1\nrelease_branch: release\n")
+
+			report, err := config.MigrateFile(path, false)
+			Expect(err).To(BeNil())
+			Expect(report.Changed()).To(BeFalse())
+			Expect(report.String()).To(ContainSubstring("is up to date at version 1"))
+		})
+
+		g.It("should refuse a file from a newer version of train", func() {
+			write("version: 99\n")
+
+			_, err := config.MigrateFile(path, false)
+			Expect(err).To(MatchError(config.ErrUnsupportedVersion))
+		})
+
+		g.It("should refuse a version that is not a number", func() {
+			write("version: latest\n")
+
+			_, err := config.MigrateFile(path, false)
+			Expect(err).To(MatchError(config.ErrUnsupportedVersion))
+		})
+	})
+}
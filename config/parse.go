@@ -3,21 +3,21 @@ package config
 import (
 	"fmt"
 	"os"
-	"os/user"
 	"path/filepath"
 
 	"gopkg.in/yaml.v2"
 )
 
-// ParseFromFile reads the train config file from the home directory. It returns
-// any errors it encounters with parsing the file.
+// ParseFromFile reads the train config file from the home directory. A file
+// from an older version is upgraded as it is read, but left as it is on disk
+// until migrated. It returns any errors it encounters with parsing the file.
 func ParseFromFile() (*Config, error) {
-	usr, err := user.Current()
+	home, err := homeDir()
 	if err != nil {
 		return nil, fmt.Errorf("Failed getting home directory: %v", err.Error())
 	}
 
-	conf := DefaultConfig
+	conf := Default()
 	dExists, err := DirExists()
 	if err != nil {
 		return nil, fmt.Errorf("config: parse from file: dir exists: %v", err.Error())
@@ -29,7 +29,7 @@ func ParseFromFile() (*Config, error) {
 			return nil, fmt.Errorf("config: parse from file: create config dir: %v", err.Error())
 		}
 
-		return &conf, nil
+		return conf, nil
 	}
 
 	fExists, err := FileExists()
@@ -38,32 +38,206 @@ func ParseFromFile() (*Config, error) {
 	}
 
 	if !fExists {
-		return &conf, nil
+		return conf, nil
 	}
 
-	b, err := os.ReadFile(filepath.Join(usr.HomeDir, confDir, confFile))
+	path := filepath.Join(home, confDir, confFile)
+
+	b, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to read config file: %v", err.Error())
 	}
 
-	err = yaml.Unmarshal(b, &conf)
+	b, report, err := Migrate(b)
+	if err != nil {
+		return nil, fmt.Errorf("config: migrate: %s: %w", path, err)
+	}
+
+	if report.Changed() {
+		fmt.Fprintf(os.Stderr, "%s is version %d, run `train config migrate` to upgrade it to version %d\n", path, report.FromVersion, report.ToVersion)
+	}
+
+	err = yaml.UnmarshalStrict(b, conf)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to unmarshal config file: %v", err.Error())
 	}
 
-	return &conf, nil
+	return conf, nil
+}
+
+// LoadOptions represents the inputs for building the effective config beyond
+// the config files train always reads.
+type LoadOptions struct {
+	// Path is an additional config file to layer over the others
+	Path string
+	// Profile is the name of the profile to apply
+	Profile string
+	// Entity is the org or user being acted on, used to select a profile when
+	// none is named
+	Entity string
+	// Lookup is consulted for an override of each field
+	Lookup func(*Field) (string, bool)
+}
+
+// Load builds the effective config by layering the user config file, the
+// project config file found from the working directory, the explicitly
+// provided config file, the selected profile, and finally any overrides on
+// top of the defaults. Later layers override the values of earlier ones. It
+// returns any errors it encounters with reading or parsing the files or the
+// overrides.
+func Load(opts *LoadOptions) (*Config, error) {
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+
+	conf, err := ParseFromFile()
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := FindProjectFile()
+	if err != nil {
+		return nil, fmt.Errorf("config: load: %v", err.Error())
+	}
+
+	if project != "" {
+		err = conf.mergeProjectFile(project)
+		if err != nil {
+			return nil, fmt.Errorf("config: load: project file: %w", err)
+		}
+	}
+
+	if opts.Path != "" {
+		err = conf.mergeFile(opts.Path)
+		if err != nil {
+			return nil, fmt.Errorf("config: load: %v", err.Error())
+		}
+	}
+
+	profile, err := conf.SelectProfile(opts.Profile, opts.Entity)
+	if err != nil {
+		return nil, fmt.Errorf("config: load: %w", err)
+	}
+
+	if profile != "" {
+		err = conf.ApplyProfile(profile)
+		if err != nil {
+			return nil, fmt.Errorf("config: load: %w", err)
+		}
+	}
+
+	if opts.Lookup != nil {
+		for _, f := range Fields {
+			v, ok := opts.Lookup(f)
+			if !ok {
+				continue
+			}
+
+			err = f.Override(conf, v)
+			if err != nil {
+				return nil, fmt.Errorf("config: load: override: %v", err.Error())
+			}
+		}
+	}
+
+	return conf, nil
+}
+
+// FindProjectFile looks for a project config file in the current working
+// directory and each of its parents, stopping at the root of the git
+// repository containing it. It returns the path of the file found, or an empty
+// string if there is none.
+func FindProjectFile() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("get working directory: %v", err.Error())
+	}
+
+	for {
+		f := filepath.Join(dir, projectFile)
+
+		_, err := os.Stat(f)
+		if err == nil {
+			return f, nil
+		}
+
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("stat project file: %v", err.Error())
+		}
+
+		_, err = os.Stat(filepath.Join(dir, ".git"))
+		if err == nil {
+			return "", nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
+// mergeFile reads the config file at the path given and overlays any values
+// it sets onto the config.
+func (c *Config) mergeFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read file: %v", err.Error())
+	}
+
+	b, _, err = Migrate(b)
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", path, err)
+	}
+
+	err = yaml.UnmarshalStrict(b, c)
+	if err != nil {
+		return fmt.Errorf("unmarshal %s: %v", path, err.Error())
+	}
+
+	return nil
+}
+
+// mergeProjectFile reads the project config file at the path given and
+// overlays any values it sets onto the config. A project file comes with
+// whichever repo train is run from, so it may only set the fields a
+// ProjectConfig holds, and never where the token is sent or what is run.
+func (c *Config) mergeProjectFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read file: %v", err.Error())
+	}
+
+	b, _, err = Migrate(b)
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", path, err)
+	}
+
+	err = yaml.UnmarshalStrict(b, &ProjectConfig{})
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrProjectField, path, err.Error())
+	}
+
+	err = yaml.UnmarshalStrict(b, c)
+	if err != nil {
+		return fmt.Errorf("unmarshal %s: %v", path, err.Error())
+	}
+
+	return nil
 }
 
 // DirExists returns a bool and error representing whether or not a config
 // directory exists for the current user, and any errors it encounters with
 // statting the existence of the directory.
 func DirExists() (bool, error) {
-	usr, err := user.Current()
+	home, err := homeDir()
 	if err != nil {
 		return false, fmt.Errorf("Failed getting home directory: %v", err.Error())
 	}
 
-	_, err = os.Stat(filepath.Join(usr.HomeDir, confDir))
+	_, err = os.Stat(filepath.Join(home, confDir))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return false, nil
@@ -78,12 +252,12 @@ func DirExists() (bool, error) {
 // config file exists for the current user, and any errors it encounters with
 // statting the existence of the file.
 func FileExists() (bool, error) {
-	usr, err := user.Current()
+	home, err := homeDir()
 	if err != nil {
 		return false, fmt.Errorf("Failed getting home directory: %v", err.Error())
 	}
 
-	_, err = os.Stat(filepath.Join(usr.HomeDir, confDir, confFile))
+	_, err = os.Stat(filepath.Join(home, confDir, confFile))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return false, nil
@@ -97,12 +271,12 @@ func FileExists() (bool, error) {
 // CreateDir creates the config directory and all necessary parent directories
 // missing. It returns any error it encounters with creating the directory.
 func CreateDir() error {
-	usr, err := user.Current()
+	home, err := homeDir()
 	if err != nil {
 		return fmt.Errorf("config: get home directory: %v", err.Error())
 	}
 
-	err = os.MkdirAll(filepath.Join(usr.HomeDir, confDir), 0700)
+	err = os.MkdirAll(filepath.Join(home, confDir), 0700)
 	if err != nil {
 		return fmt.Errorf("config: write file: %v", err.Error())
 	}
@@ -0,0 +1,156 @@
+package config_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/train/config"
+	. "github.com/onsi/gomega"
+)
+
+func TestLoad(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	write := func(path, content string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+	}
+
+	g.Describe("Load", func() {
+		var (
+			home    string
+			project string
+			wd      string
+		)
+
+		g.BeforeEach(func() {
+			home = t.TempDir()
+			config.SetHomeDir(t, home)
+
+			write(filepath.Join(home, ".train", "config"), fmt.Sprintf(`version: %d
+release_branch: user
+merge_method: merge
+github.com:
+  token: user-token
+  limits:
+    request_per_second: 5
+    burst: 5
+    retries: 1
+profiles:
+  work:
+    release_branch: profile
+`, config.CurrentVersion))
+
+			project = t.TempDir()
+			Expect(os.Mkdir(filepath.Join(project, ".git"), 0700)).To(Succeed())
+
+			var err error
+			wd, err = os.Getwd()
+			Expect(err).To(BeNil())
+			Expect(os.Chdir(project)).To(Succeed())
+		})
+
+		g.AfterEach(func() {
+			Expect(os.Chdir(wd)).To(Succeed())
+		})
+
+		g.It("should read the user config file", func() {
+			c, err := config.Load(nil)
+			Expect(err).To(BeNil())
+			Expect(c.ReleaseBranch).To(Equal("user"))
+			Expect(c.Github.Token).To(Equal("user-token"))
+		})
+
+		g.It("should layer the project file over the user file", func() {
+			write(filepath.Join(project, ".train.yaml"), `
+release_branch: project
+github.com:
+  limits:
+    burst: 15
+  ignores:
+    repos:
+      - sandbox
+`)
+
+			c, err := config.Load(nil)
+			Expect(err).To(BeNil())
+			Expect(c.ReleaseBranch).To(Equal("project"))
+			Expect(c.MergeMethod).To(Equal("merge"))
+			Expect(c.Github.Token).To(Equal("user-token"))
+			Expect(c.Github.Limits.Burst).To(Equal(15))
+			Expect(c.Github.Ignores.Repos).To(Equal([]string{"sandbox"}))
+		})
+
+		g.It("should find the project file in a parent up to the git root", func() {
+			write(filepath.Join(project, ".train.yaml"), "release_branch: project\n")
+
+			sub := filepath.Join(project, "sub")
+			Expect(os.Mkdir(sub, 0700)).To(Succeed())
+			Expect(os.Chdir(sub)).To(Succeed())
+
+			c, err := config.Load(nil)
+			Expect(err).To(BeNil())
+			Expect(c.ReleaseBranch).To(Equal("project"))
+		})
+
+		g.It("should layer the given file over the project file", func() {
+			write(filepath.Join(project, ".train.yaml"), "release_branch: project\nmerge_method: squash\n")
+
+			given := filepath.Join(t.TempDir(), "train.yaml")
+			write(given, "release_branch: given\n")
+
+			c, err := config.Load(&config.LoadOptions{Path: given})
+			Expect(err).To(BeNil())
+			Expect(c.ReleaseBranch).To(Equal("given"))
+			Expect(c.MergeMethod).To(Equal("squash"))
+		})
+
+		g.It("should layer the profile over the given file", func() {
+			given := filepath.Join(t.TempDir(), "train.yaml")
+			write(given, "release_branch: given\nmerge_method: rebase\n")
+
+			c, err := config.Load(&config.LoadOptions{Path: given, Profile: "work"})
+			Expect(err).To(BeNil())
+			Expect(c.ReleaseBranch).To(Equal("profile"))
+			Expect(c.MergeMethod).To(Equal("rebase"))
+		})
+
+		g.It("should layer environment variables and flags over the profile", func() {
+			c, err := config.Load(&config.LoadOptions{
+				Profile: "work",
+				Lookup: func(f *config.Field) (string, bool) {
+					if f.Path == "release_branch" {
+						return "flag", true
+					}
+
+					return "", false
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(c.ReleaseBranch).To(Equal("flag"))
+			Expect(c.Explicit("release_branch")).To(BeTrue())
+		})
+
+		g.It("should refuse project files setting anything beyond the project settings", func() {
+			for _, content := range []string{
+				"github.com:\n  api_url: https://evil.example/\n",
+				"github.com:\n  token: stolen\n",
+				"github.com:\n  credential:\n    store: helper\n    helper: rm -rf ~\n",
+				"github.com:\n  app:\n    private_key_file: /etc/shadow\n",
+				"github.com:\n  ensures:\n    repos:\n      - everything\n",
+				"audit_log: /tmp/audit.jsonl\n",
+				"profiles:\n  work:\n    release_branch: project\n",
+			} {
+				write(filepath.Join(project, ".train.yaml"), content)
+
+				_, err := config.Load(nil)
+				Expect(errors.Is(err, config.ErrProjectField)).To(BeTrue(), content)
+			}
+		})
+	})
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// Profile represents a named set of settings, such as those for a single org
+// or host, that override the top level settings of the config when selected.
+type Profile struct {
+	Entities      []string    `yaml:"entities,omitempty"`
+	ReleaseBranch string      `yaml:"release_branch,omitempty"`
+	MergeMethod   string      `yaml:"merge_method,omitempty"`
+	Github        *GithubHost `yaml:"github.com,omitempty"`
+}
+
+// SelectProfile returns the name of the profile to use. A profile requested
+// by name must exist. Otherwise the first profile, by name, listing the entity
+// given is selected. It returns an empty string if no profile applies.
+func (c *Config) SelectProfile(name, entity string) (string, error) {
+	if name != "" {
+		if _, ok := c.Profiles[name]; !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnknownProfile, name)
+		}
+
+		return name, nil
+	}
+
+	if entity == "" {
+		return "", nil
+	}
+
+	entity = entityOf(entity)
+
+	match := ""
+	for n, p := range c.Profiles {
+		if !contains(p.Entities, entity) {
+			continue
+		}
+
+		if match == "" || n < match {
+			match = n
+		}
+	}
+
+	return match, nil
+}
+
+// ApplyProfile overlays the settings of the named profile onto the top level
+// settings of the config, key by key. Settings the profile leaves out are
+// unchanged, and those it sets are marked explicit.
+func (c *Config) ApplyProfile(name string) error {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownProfile, name)
+	}
+
+	if p.ReleaseBranch != "" {
+		c.ReleaseBranch = p.ReleaseBranch
+		c.markExplicit("release_branch")
+	}
+
+	if p.MergeMethod != "" {
+		c.MergeMethod = p.MergeMethod
+		c.markExplicit("merge_method")
+	}
+
+	if p.Github == nil {
+		return nil
+	}
+
+	h := c.host()
+
+	if p.Github.APIURL != "" {
+		h.APIURL = p.Github.APIURL
+	}
+
+	if p.Github.Token != "" || p.Github.Credential != nil {
+		h.Token = p.Github.Token
+		h.Credential = p.Github.Credential
+	}
+
+	if a := p.Github.App; a != nil {
+		app := c.app()
+
+		if a.ID != 0 {
+			app.ID = a.ID
+		}
+
+		if a.InstallationID != 0 {
+			app.InstallationID = a.InstallationID
+		}
+
+		if a.PrivateKeyFile != "" {
+			app.PrivateKeyFile = a.PrivateKeyFile
+		}
+	}
+
+	if l := p.Github.Limits; l != nil {
+		limits := c.limits()
+
+		if l.isSet("request_per_second") {
+			limits.RequestsPerSecond = l.RequestsPerSecond
+		}
+
+		if l.isSet("burst") {
+			limits.Burst = l.Burst
+		}
+
+		if l.isSet("retries") {
+			limits.Retries = l.Retries
+		}
+	}
+
+	if i := p.Github.Ignores; i != nil {
+		ignores := c.ignores()
+
+		if i.Repos != nil {
+			ignores.Repos = i.Repos
+		}
+
+		if i.Topics != nil {
+			ignores.Topics = i.Topics
+		}
+	}
+
+	if e := p.Github.Ensures; e != nil {
+		ensures := c.ensures()
+
+		if e.Repos != nil {
+			ensures.Repos = e.Repos
+		}
+
+		if e.Topics != nil {
+			ensures.Topics = e.Topics
+		}
+	}
+
+	return nil
+}
+
+// HostFor returns the github block of the named profile, creating the
+// profile if it does not exist yet, or the top level block if no profile is
+// named.
+func (c *Config) HostFor(profile string) *GithubHost {
+	if profile == "" {
+		return c.host()
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = map[string]*Profile{}
+	}
+
+	p, ok := c.Profiles[profile]
+	if !ok {
+		p = &Profile{}
+		c.Profiles[profile] = p
+	}
+
+	if p.Github == nil {
+		p.Github = &GithubHost{}
+	}
+
+	return p.Github
+}
+
+// entityOf returns the org or user portion of an entity, which may be given
+// as a bare name or in the form org/team.
+func entityOf(entity string) string {
+	e, _, _ := strings.Cut(entity, "/")
+	return e
+}
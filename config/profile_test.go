@@ -0,0 +1,137 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/train/config"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+func TestProfiles(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ApplyProfile", func() {
+		load := func(content string) *config.Config {
+			c := config.Default()
+			Expect(yaml.UnmarshalStrict([]byte(content), c)).To(Succeed())
+
+			return c
+		}
+
+		g.It("should only override the limits a profile sets", func() {
+			c := load(`
+github.com:
+  limits:
+    request_per_second: 5
+    burst: 10
+    retries: 2
+profiles:
+  work:
+    github.com:
+      limits:
+        burst: 50
+`)
+
+			Expect(c.ApplyProfile("work")).To(Succeed())
+			Expect(c.Github.Limits.RequestsPerSecond).To(Equal(5))
+			Expect(c.Github.Limits.Burst).To(Equal(50))
+			Expect(c.Github.Limits.Retries).To(Equal(2))
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		g.It("should let a profile turn retries off", func() {
+			c := load(`
+profiles:
+  work:
+    github.com:
+      limits:
+        retries: 0
+`)
+
+			Expect(c.ApplyProfile("work")).To(Succeed())
+			Expect(c.Github.Limits.RequestsPerSecond).To(Equal(10))
+			Expect(c.Github.Limits.Burst).To(Equal(25))
+			Expect(c.Github.Limits.Retries).To(Equal(0))
+		})
+
+		g.It("should only override the lists and app settings a profile sets", func() {
+			c := load(`
+github.com:
+  app:
+    id: 1
+    private_key_file: /keys/train.pem
+  ignores:
+    repos:
+      - sandbox
+    topics:
+      - deprecated
+  ensures:
+    repos:
+      - steward
+profiles:
+  work:
+    github.com:
+      app:
+        installation_id: 9
+      ignores:
+        topics:
+          - archived
+      ensures:
+        topics:
+          - service
+`)
+
+			Expect(c.ApplyProfile("work")).To(Succeed())
+
+			Expect(c.Github.App.ID).To(Equal(int64(1)))
+			Expect(c.Github.App.InstallationID).To(Equal(int64(9)))
+			Expect(c.Github.App.PrivateKeyFile).To(Equal("/keys/train.pem"))
+
+			Expect(c.Github.Ignores.Repos).To(Equal([]string{"sandbox"}))
+			Expect(c.Github.Ignores.Topics).To(Equal([]string{"archived"}))
+
+			Expect(c.Github.Ensures.Repos).To(Equal([]string{"steward"}))
+			Expect(c.Github.Ensures.Topics).To(Equal([]string{"service"}))
+		})
+
+		g.It("should mark the settings a profile sets as explicit", func() {
+			c := load(`
+profiles:
+  work:
+    merge_method: squash
+`)
+
+			Expect(c.ApplyProfile("work")).To(Succeed())
+			Expect(c.MergeMethod).To(Equal("squash"))
+			Expect(c.Explicit("merge_method")).To(BeTrue())
+			Expect(c.Explicit("release_branch")).To(BeFalse())
+		})
+
+		g.It("should return an error for an unknown profile", func() {
+			c := config.Default()
+			Expect(c.ApplyProfile("missing")).To(MatchError(config.ErrUnknownProfile))
+		})
+	})
+
+	g.Describe("SelectProfile", func() {
+		g.It("should select the first profile listing the entity", func() {
+			c := config.Default()
+			c.Profiles = map[string]*config.Profile{
+				"b": {Entities: []string{"gomicro"}},
+				"a": {Entities: []string{"GoMicro"}},
+				"c": {Entities: []string{"other"}},
+			}
+
+			name, err := c.SelectProfile("", "gomicro/core")
+			Expect(err).To(BeNil())
+			Expect(name).To(Equal("a"))
+
+			name, err = c.SelectProfile("", "nobody")
+			Expect(err).To(BeNil())
+			Expect(name).To(BeEmpty())
+		})
+	})
+}
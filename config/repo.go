@@ -0,0 +1,45 @@
+package config
+
+import "errors"
+
+var ErrProjectField = errors.New("project config files may only set version, release_branch, merge_method, and github.com limits and ignores")
+
+// ProjectConfig represents the settings a project config file may set. The
+// file is found in whatever repo train is run from, which may not be trusted,
+// so nothing deciding where the token is sent, how credentials are found, or
+// where files are written may be set by one.
+type ProjectConfig struct {
+	Version       int          `yaml:"version"`
+	ReleaseBranch string       `yaml:"release_branch"`
+	MergeMethod   string       `yaml:"merge_method"`
+	Github        *ProjectHost `yaml:"github.com"`
+}
+
+// ProjectHost represents the host settings a project config file may set.
+type ProjectHost struct {
+	Ignores *GithubIgnores `yaml:"ignores"`
+	Limits  *Limits        `yaml:"limits"`
+}
+
+// RepoConfig represents the per repo overrides train reads from the config
+// file kept within a repo.
+type RepoConfig struct {
+	Enabled       *bool  `yaml:"enabled"`
+	ReleaseBranch string `yaml:"release_branch"`
+	MergeMethod   string `yaml:"merge_method"`
+}
+
+// IsEnabled returns whether train should act on the repo. Repos are enabled
+// unless explicitly opted out.
+func (r *RepoConfig) IsEnabled() bool {
+	return r == nil || r.Enabled == nil || *r.Enabled
+}
+
+// OrgConfig represents the org wide defaults train reads from the config file
+// kept within an org's .github repo. They apply to every repo in the org,
+// beneath any overrides from the repo itself.
+type OrgConfig struct {
+	ReleaseBranch string         `yaml:"release_branch"`
+	MergeMethod   string         `yaml:"merge_method"`
+	Ignores       *GithubIgnores `yaml:"ignores"`
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidConfig = errors.New("invalid config")
+
+	repoNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)?$`)
+)
+
+// ValidationError represents the collection of problems found with a config.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v:\n  %s", ErrInvalidConfig, strings.Join(e.Problems, "\n  "))
+}
+
+// Is allows a ValidationError to be matched against ErrInvalidConfig.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// Validate checks the config for values train cannot run with. It returns a
+// ValidationError listing every problem found, or nil if there are none.
+func (c *Config) Validate() error {
+	var problems []string
+
+	if strings.TrimSpace(c.ReleaseBranch) == "" {
+		problems = append(problems, "release_branch: must not be empty")
+	}
+
+	problems = append(problems, validateMergeMethod("merge_method", c.MergeMethod)...)
+
+	if c.Github == nil {
+		problems = append(problems, "github.com: section is missing")
+		return &ValidationError{Problems: problems}
+	}
+
+	if cred := c.Github.Credential; cred != nil {
+		switch cred.Store {
+		case "file", "keyring":
+		case "helper":
+			if strings.TrimSpace(cred.Helper) == "" {
+				problems = append(problems, "github.com.credential.helper: must be set for the helper store")
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("github.com.credential.store: must be one of file, keyring, helper: got %s", cred.Store))
+		}
+	}
+
+	if app := c.Github.App; app.IsSet() {
+		if app.ID < 1 {
+			problems = append(problems, "github.com.app.id: must be set when using an app")
+		}
+
+		if strings.TrimSpace(app.PrivateKeyFile) == "" {
+			problems = append(problems, "github.com.app.private_key_file: must be set when using an app")
+		}
+	}
+
+	if c.Github.Limits == nil {
+		problems = append(problems, "github.com.limits: section is missing")
+	} else {
+		if c.Github.Limits.RequestsPerSecond < 1 {
+			problems = append(problems, fmt.Sprintf("github.com.limits.request_per_second: must be positive: got %d", c.Github.Limits.RequestsPerSecond))
+		}
+
+		if c.Github.Limits.Burst < 1 {
+			problems = append(problems, fmt.Sprintf("github.com.limits.burst: must be positive: got %d", c.Github.Limits.Burst))
+		}
+
+		if c.Github.Limits.Retries < 0 {
+			problems = append(problems, fmt.Sprintf("github.com.limits.retries: must not be negative: got %d", c.Github.Limits.Retries))
+		}
+	}
+
+	if c.Github.Ignores != nil {
+		problems = append(problems, validateRepoNames("github.com.ignores.repos", c.Github.Ignores.Repos)...)
+	}
+
+	if c.Github.Ensures != nil {
+		problems = append(problems, validateRepoNames("github.com.ensures.repos", c.Github.Ensures.Repos)...)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// Validate checks an org config file for values train cannot run with. Every
+// setting is optional, but those set must be usable. It returns a
+// ValidationError listing every problem found, or nil if there are none.
+func (o *OrgConfig) Validate() error {
+	var problems []string
+
+	problems = append(problems, validateBranch("release_branch", o.ReleaseBranch)...)
+	problems = append(problems, validateMergeMethod("merge_method", o.MergeMethod)...)
+
+	if o.Ignores != nil {
+		problems = append(problems, validateRepoNames("ignores.repos", o.Ignores.Repos)...)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// Validate checks a repo config file for values train cannot run with. Every
+// setting is optional, but those set must be usable. It returns a
+// ValidationError listing every problem found, or nil if there are none.
+func (r *RepoConfig) Validate() error {
+	var problems []string
+
+	problems = append(problems, validateBranch("release_branch", r.ReleaseBranch)...)
+	problems = append(problems, validateMergeMethod("merge_method", r.MergeMethod)...)
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// validateBranch checks an optional branch name is not only whitespace.
+func validateBranch(path, branch string) []string {
+	if branch != "" && strings.TrimSpace(branch) == "" {
+		return []string{fmt.Sprintf("%s: must not be blank", path)}
+	}
+
+	return nil
+}
+
+func validateMergeMethod(path, method string) []string {
+	f, _ := LookupField("merge_method")
+	if method != "" && !contains(f.Values, method) {
+		return []string{fmt.Sprintf("%s: must be one of %s: got %s", path, strings.Join(f.Values, ", "), method)}
+	}
+
+	return nil
+}
+
+func validateRepoNames(path string, names []string) []string {
+	var problems []string
+
+	for i, n := range names {
+		if !repoNamePattern.MatchString(n) {
+			problems = append(problems, fmt.Sprintf("%s[%d]: must be of the form name or owner/name: got %q", path, i, n))
+		}
+	}
+
+	return problems
+}
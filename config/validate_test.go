@@ -0,0 +1,84 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/train/config"
+	. "github.com/onsi/gomega"
+)
+
+func TestValidate(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Config", func() {
+		g.It("should accept the defaults", func() {
+			Expect(config.Default().Validate()).To(Succeed())
+		})
+
+		g.It("should accept a missing ignores section", func() {
+			c := config.Default()
+			c.Github.Ignores = nil
+
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		g.It("should list every problem found", func() {
+			c := config.Default()
+			c.ReleaseBranch = " "
+			c.MergeMethod = "fastforward"
+			c.Github.Limits.RequestsPerSecond = 0
+			c.Github.Ignores.Repos = []string{"gomicro/train/extra"}
+
+			err := c.Validate()
+			Expect(err).To(MatchError(config.ErrInvalidConfig))
+
+			verr, ok := err.(*config.ValidationError)
+			Expect(ok).To(BeTrue())
+			Expect(verr.Problems).To(HaveLen(4))
+			Expect(verr.Problems[0]).To(HavePrefix("release_branch:"))
+			Expect(verr.Problems[1]).To(HavePrefix("merge_method:"))
+			Expect(verr.Problems[2]).To(HavePrefix("github.com.limits.request_per_second:"))
+			Expect(verr.Problems[3]).To(HavePrefix("github.com.ignores.repos[0]:"))
+		})
+
+		g.It("should reject a missing github section", func() {
+			c := config.Default()
+			c.Github = nil
+
+			Expect(c.Validate()).To(MatchError(ContainSubstring("github.com: section is missing")))
+		})
+	})
+
+	g.Describe("OrgConfig", func() {
+		g.It("should accept an empty file", func() {
+			o := &config.OrgConfig{}
+			Expect(o.Validate()).To(Succeed())
+		})
+
+		g.It("should reject unusable settings", func() {
+			o := &config.OrgConfig{
+				ReleaseBranch: "  ",
+				MergeMethod:   "fastforward",
+				Ignores:       &config.GithubIgnores{Repos: []string{"not a repo"}},
+			}
+
+			err := o.Validate()
+			Expect(err).To(MatchError(config.ErrInvalidConfig))
+			Expect(err.(*config.ValidationError).Problems).To(HaveLen(3))
+		})
+	})
+
+	g.Describe("RepoConfig", func() {
+		g.It("should accept an empty file", func() {
+			r := &config.RepoConfig{}
+			Expect(r.Validate()).To(Succeed())
+		})
+
+		g.It("should reject an unknown merge method", func() {
+			r := &config.RepoConfig{MergeMethod: "fastforward"}
+			Expect(r.Validate()).To(MatchError(ContainSubstring("merge_method: must be one of merge, squash, rebase")))
+		})
+	})
+}
@@ -0,0 +1,67 @@
+// Package credential provides storage for the tokens train authenticates
+// with, so they need not be kept in plain text within the config file.
+package credential
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gomicro/train/config"
+)
+
+const (
+	// StoreFile keeps the token within the config file
+	StoreFile = "file"
+	// StoreKeyring keeps the token within the OS keyring
+	StoreKeyring = "keyring"
+	// StoreHelper keeps the token with an external credential helper
+	StoreHelper = "helper"
+)
+
+var (
+	ErrNotFound     = errors.New("credential not found")
+	ErrUnknownStore = errors.New("unknown credential store")
+)
+
+// Store represents a place tokens can be kept, addressed by key.
+type Store interface {
+	Get(key string) (string, error)
+	Store(key, secret string) error
+	Erase(key string) error
+}
+
+// New returns the store described by the credential reference given.
+func New(cred *config.Credential) (Store, error) {
+	switch cred.Store {
+	case StoreKeyring:
+		return &keyring{}, nil
+	case StoreHelper:
+		return &helper{command: cred.Helper}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStore, cred.Store)
+	}
+}
+
+// Resolve fills in the token of the config's host from the credential store
+// it references. Tokens already present, from the file or an override, are
+// left as they are.
+func Resolve(c *config.Config) error {
+	h := c.Github
+	if h == nil || h.Token != "" || h.Credential == nil || h.Credential.Store == StoreFile {
+		return nil
+	}
+
+	s, err := New(h.Credential)
+	if err != nil {
+		return fmt.Errorf("credential: %w", err)
+	}
+
+	tkn, err := s.Get(h.Credential.Key)
+	if err != nil {
+		return fmt.Errorf("credential: %s: %w", h.Credential.Store, err)
+	}
+
+	h.Token = tkn
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package credential
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// helper is a store backed by an external command speaking the git
+// credential helper protocol. The command is invoked with an action of get,
+// store, or erase, and exchanges key=value lines over stdin and stdout.
+type helper struct {
+	command string
+}
+
+func (h *helper) Get(key string) (string, error) {
+	out, err := h.run("get", map[string]string{"host": key})
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		k, v, found := strings.Cut(scanner.Text(), "=")
+		if found && k == "password" && v != "" {
+			return v, nil
+		}
+	}
+
+	return "", ErrNotFound
+}
+
+func (h *helper) Store(key, secret string) error {
+	_, err := h.run("store", map[string]string{"host": key, "username": "train", "password": secret})
+	return err
+}
+
+func (h *helper) Erase(key string) error {
+	_, err := h.run("erase", map[string]string{"host": key})
+	return err
+}
+
+func (h *helper) run(action string, attrs map[string]string) ([]byte, error) {
+	parts := strings.Fields(h.command)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("helper: no command configured")
+	}
+
+	var in bytes.Buffer
+	fmt.Fprintln(&in, "protocol=https")
+	for _, k := range []string{"host", "username", "password"} {
+		if v, ok := attrs[k]; ok {
+			fmt.Fprintf(&in, "%s=%s\n", k, v)
+		}
+	}
+	fmt.Fprintln(&in)
+
+	cmd := exec.Command(parts[0], append(parts[1:], action)...) //nolint: gosec
+	cmd.Stdin = &in
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("helper: %s: %w", action, err)
+	}
+
+	return out, nil
+}
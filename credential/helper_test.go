@@ -0,0 +1,134 @@
+package credential
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/gomicro/train/config"
+	. "github.com/onsi/gomega"
+)
+
+// fakeHelper speaks the git credential helper protocol, keeping each secret in
+// a file named for its host within the directory it is given, and the input of
+// the last call in a file named input.
+const fakeHelper = `#!/bin/sh
+dir="$1"
+action="$2"
+host=""
+password=""
+
+: > "$dir/input"
+while IFS= read -r line; do
+	echo "$line" >> "$dir/input"
+	[ -z "$line" ] && break
+	case "$line" in
+		host=*) host="${line#host=}" ;;
+		password=*) password="${line#password=}" ;;
+	esac
+done
+
+case "$action" in
+	get)
+		if [ -f "$dir/$host" ]; then
+			printf 'protocol=https\nhost=%s\nusername=train\npassword=%s\n\n' "$host" "$(cat "$dir/$host")"
+		fi
+		;;
+	store) printf '%s' "$password" > "$dir/$host" ;;
+	erase) rm -f "$dir/$host" ;;
+	*) exit 1 ;;
+esac
+`
+
+func TestHelper(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Helper Store", func() {
+		var dir string
+		var s Store
+
+		g.BeforeEach(func() {
+			dir = t.TempDir()
+
+			script := filepath.Join(dir, "git-credential-fake")
+			Expect(os.WriteFile(script, []byte(fakeHelper), 0700)).To(Succeed())
+
+			var err error
+			s, err = New(&config.Credential{Store: StoreHelper, Helper: script + " " + dir})
+			Expect(err).To(BeNil())
+		})
+
+		g.It("should store, get, and erase a secret", func() {
+			Expect(s.Store("github.com", "s3cret")).To(Succeed())
+
+			input, err := os.ReadFile(filepath.Join(dir, "input"))
+			Expect(err).To(BeNil())
+			Expect(string(input)).To(Equal("protocol=https\nhost=github.com\nusername=train\npassword=s3cret\n\n"))
+
+			secret, err := s.Get("github.com")
+			Expect(err).To(BeNil())
+			Expect(secret).To(Equal("s3cret"))
+
+			input, err = os.ReadFile(filepath.Join(dir, "input"))
+			Expect(err).To(BeNil())
+			Expect(string(input)).To(Equal("protocol=https\nhost=github.com\n\n"))
+
+			Expect(s.Erase("github.com")).To(Succeed())
+
+			_, err = s.Get("github.com")
+			Expect(err).To(MatchError(ErrNotFound))
+		})
+
+		g.It("should return not found when the helper has nothing", func() {
+			_, err := s.Get("ghe.example.com")
+			Expect(err).To(MatchError(ErrNotFound))
+		})
+
+		g.It("should return an error when the helper fails", func() {
+			s := &helper{command: "false"}
+
+			_, err := s.Get("github.com")
+			Expect(err).To(MatchError(ContainSubstring("helper: get")))
+		})
+
+		g.It("should return an error when no command is configured", func() {
+			s := &helper{command: " "}
+
+			Expect(s.Erase("github.com")).To(MatchError(ContainSubstring("no command configured")))
+		})
+	})
+
+	g.Describe("Resolve", func() {
+		g.It("should fill in the token from the store referenced", func() {
+			dir := t.TempDir()
+
+			script := filepath.Join(dir, "git-credential-fake")
+			Expect(os.WriteFile(script, []byte(fakeHelper), 0700)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "work"), []byte("abc123"), 0600)).To(Succeed())
+
+			c := config.Default()
+			c.Github.Credential = &config.Credential{Store: StoreHelper, Key: "work", Helper: script + " " + dir}
+
+			Expect(Resolve(c)).To(Succeed())
+			Expect(c.Github.Token).To(Equal("abc123"))
+		})
+
+		g.It("should leave a token already present", func() {
+			c := config.Default()
+			c.Github.Token = "from-flag"
+			c.Github.Credential = &config.Credential{Store: StoreHelper, Helper: "false"}
+
+			Expect(Resolve(c)).To(Succeed())
+			Expect(c.Github.Token).To(Equal("from-flag"))
+		})
+
+		g.It("should return an error for an unknown store", func() {
+			c := config.Default()
+			c.Github.Credential = &config.Credential{Store: "vault"}
+
+			Expect(Resolve(c)).To(MatchError(ErrUnknownStore))
+		})
+	})
+}
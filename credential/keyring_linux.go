@@ -0,0 +1,70 @@
+package credential
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	secretTool = "secret-tool"
+	service    = "train"
+)
+
+// keyring is a store backed by the Secret Service, accessed through the
+// secret-tool command shipped with libsecret.
+type keyring struct{}
+
+// KeyringAvailable reports whether the keyring store may be used, which
+// needs secret-tool installed.
+func KeyringAvailable() bool {
+	_, err := exec.LookPath(secretTool)
+	return err == nil
+}
+
+func (k *keyring) Get(key string) (string, error) {
+	var out bytes.Buffer
+
+	cmd := exec.Command(secretTool, "lookup", "service", service, "key", key)
+	cmd.Stdout = &out
+
+	err := cmd.Run()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", ErrNotFound
+		}
+
+		return "", fmt.Errorf("keyring: lookup: %w", err)
+	}
+
+	secret := strings.TrimSpace(out.String())
+	if secret == "" {
+		return "", ErrNotFound
+	}
+
+	return secret, nil
+}
+
+func (k *keyring) Store(key, secret string) error {
+	cmd := exec.Command(secretTool, "store", "--label", fmt.Sprintf("train token for %s", key), "service", service, "key", key)
+	cmd.Stdin = strings.NewReader(secret)
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("keyring: store: %w", err)
+	}
+
+	return nil
+}
+
+func (k *keyring) Erase(key string) error {
+	err := exec.Command(secretTool, "clear", "service", service, "key", key).Run()
+	if err != nil {
+		return fmt.Errorf("keyring: clear: %w", err)
+	}
+
+	return nil
+}
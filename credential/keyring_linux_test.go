@@ -0,0 +1,78 @@
+package credential
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+// fakeSecretTool stands in for secret-tool, keeping each secret in a file
+// named for its key within the directory it runs in, and the arguments of the
+// last call in a file named args.
+const fakeSecretTool = `#!/bin/sh
+dir="$(dirname "$0")"
+echo "$@" > "$dir/args"
+
+action="$1"
+shift
+key=""
+while [ $# -gt 0 ]; do
+	[ "$1" = "key" ] && key="$2"
+	shift
+done
+
+case "$action" in
+	lookup)
+		[ -f "$dir/secret-$key" ] || exit 1
+		cat "$dir/secret-$key"
+		;;
+	store) cat > "$dir/secret-$key" ;;
+	clear) rm -f "$dir/secret-$key" ;;
+	*) exit 2 ;;
+esac
+`
+
+func TestKeyring(t *testing.T) {
+	g := goblin.Goblin(t)
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Keyring Store", func() {
+		var dir string
+
+		g.BeforeEach(func() {
+			dir = t.TempDir()
+
+			Expect(os.WriteFile(filepath.Join(dir, secretTool), []byte(fakeSecretTool), 0700)).To(Succeed())
+			t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		})
+
+		g.It("should store, get, and erase a secret", func() {
+			k := &keyring{}
+
+			Expect(k.Store("work", "s3cret")).To(Succeed())
+
+			args, err := os.ReadFile(filepath.Join(dir, "args"))
+			Expect(err).To(BeNil())
+			Expect(string(args)).To(Equal("store --label train token for work service train key work\n"))
+
+			secret, err := k.Get("work")
+			Expect(err).To(BeNil())
+			Expect(secret).To(Equal("s3cret"))
+
+			Expect(k.Erase("work")).To(Succeed())
+
+			_, err = k.Get("work")
+			Expect(err).To(MatchError(ErrNotFound))
+		})
+
+		g.It("should return not found for a key never stored", func() {
+			k := &keyring{}
+
+			_, err := k.Get("missing")
+			Expect(err).To(MatchError(ErrNotFound))
+		})
+	})
+}
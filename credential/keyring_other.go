@@ -0,0 +1,34 @@
+//go:build !linux
+
+package credential
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// keyring is a store backed by the OS keyring, which is only supported on
+// linux.
+type keyring struct{}
+
+// KeyringAvailable reports whether the keyring store may be used, which it
+// never may on this platform.
+func KeyringAvailable() bool {
+	return false
+}
+
+func (k *keyring) Get(key string) (string, error) {
+	return "", k.unsupported()
+}
+
+func (k *keyring) Store(key, secret string) error {
+	return k.unsupported()
+}
+
+func (k *keyring) Erase(key string) error {
+	return k.unsupported()
+}
+
+func (k *keyring) unsupported() error {
+	return fmt.Errorf("keyring: unsupported platform: %s", runtime.GOOS)
+}
@@ -9,6 +9,7 @@ require (
 	github.com/gomicro/penname v0.1.1
 	github.com/gomicro/trust v0.0.1
 	github.com/google/go-github v17.0.0+incompatible
+	github.com/mattn/go-isatty v0.0.18
 	github.com/onsi/gomega v1.27.4
 	github.com/spf13/cobra v1.6.1
 	github.com/spf13/viper v1.15.0
@@ -26,7 +27,6 @@ require (
 	github.com/hashicorp/hcl v1.0.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/magiconair/properties v1.8.7 // indirect
-	github.com/mattn/go-isatty v0.0.18 // indirect
 	github.com/mitchellh/mapstructure v1.5.0 // indirect
 	github.com/pelletier/go-toml/v2 v2.0.7 // indirect
 	github.com/spf13/afero v1.9.5 // indirect
@@ -0,0 +1,447 @@
+// Package journal records what each train run did to each repo as it happens,
+// so an interrupted run can be reviewed and resumed.
+package journal
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomicro/train/client"
+	"github.com/gomicro/train/config"
+	"github.com/google/go-github/github"
+)
+
+const (
+	runsDir = "runs"
+	fileExt = ".jsonl"
+
+	recordStart  = "start"
+	recordResume = "resume"
+	recordResult = "result"
+	recordEnd    = "end"
+
+	StatusComplete    = "complete"
+	StatusFailed      = "failed"
+	StatusInterrupted = "interrupted"
+)
+
+var (
+	ErrNotFound        = errors.New("run not found")
+	ErrComplete        = errors.New("run already complete")
+	ErrSettingsChanged = errors.New("settings differ from those the run was started with")
+)
+
+// Header describes how a run was started, so that it may be started again
+// against the same repos.
+type Header struct {
+	ID             string    `json:"id"`
+	Action         string    `json:"action"`
+	Args           []string  `json:"args,omitempty"`
+	Team           string    `json:"team,omitempty"`
+	TeamPermission string    `json:"team_permission,omitempty"`
+	Query          string    `json:"query,omitempty"`
+	Profile        string    `json:"profile,omitempty"`
+	Settings       *Settings `json:"settings,omitempty"`
+	Started        time.Time `json:"started"`
+}
+
+// Settings represents the effective settings a run was started with, which a
+// resumed run must share to carry on the same changes.
+type Settings struct {
+	Config        string `json:"config,omitempty"`
+	Host          string `json:"host"`
+	ReleaseBranch string `json:"release_branch"`
+	MergeMethod   string `json:"merge_method,omitempty"`
+}
+
+// Check returns an error describing each setting that differs from those the
+// run was started with. Runs recorded without their settings are not checked.
+func (h *Header) Check(s *Settings) error {
+	if h.Settings == nil || s == nil {
+		return nil
+	}
+
+	var diffs []string
+	for _, d := range []struct {
+		name     string
+		was, now string
+	}{
+		{"config", h.Settings.Config, s.Config},
+		{"host", h.Settings.Host, s.Host},
+		{"release branch", h.Settings.ReleaseBranch, s.ReleaseBranch},
+		{"merge method", h.Settings.MergeMethod, s.MergeMethod},
+	} {
+		if d.was != d.now {
+			diffs = append(diffs, fmt.Sprintf("%s was %q, now %q", d.name, d.was, d.now))
+		}
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("%w: %s", ErrSettingsChanged, strings.Join(diffs, ", "))
+	}
+
+	return nil
+}
+
+// Selection returns a description of the repos the run acts upon.
+func (h *Header) Selection() string {
+	switch {
+	case h.Team != "":
+		return fmt.Sprintf("team %s", h.Team)
+	case h.Query != "":
+		return fmt.Sprintf("query %s", h.Query)
+	default:
+		return strings.Join(h.Args, " ")
+	}
+}
+
+type record struct {
+	Type   string         `json:"type"`
+	Time   time.Time      `json:"time"`
+	Header *Header        `json:"header,omitempty"`
+	Result *client.Result `json:"result,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// Run represents everything recorded for a run.
+type Run struct {
+	Header  *Header
+	Results []client.Result
+	Resumes int
+	Ended   time.Time
+	Error   string
+	status  string
+}
+
+// Status returns whether the run is complete, failed, or was interrupted
+// before it could finish.
+func (r *Run) Status() string {
+	return r.status
+}
+
+// Latest returns the most recent result recorded for each repo, as results
+// from earlier attempts are superseded when a run is resumed.
+func (r *Run) Latest() []client.Result {
+	return latest(r.Results)
+}
+
+// Count returns the number of repos recorded with the status given.
+func (r *Run) Count(status string) int {
+	n := 0
+	for _, res := range r.Latest() {
+		if res.Status == status {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Journal is an open run journal, appended to as repos are acted upon.
+type Journal struct {
+	header   *Header
+	file     *os.File
+	finished map[string]struct{}
+}
+
+// Dir returns the directory runs are recorded in for the current user.
+func Dir() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, runsDir), nil
+}
+
+// New starts the journal for a new run in the directory given, assigning the
+// run its id.
+func New(dir string, h *Header) (*Journal, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("journal: create dir: %w", err)
+	}
+
+	h.ID, err = newID()
+	if err != nil {
+		return nil, fmt.Errorf("journal: %w", err)
+	}
+
+	h.Started = time.Now()
+
+	f, err := os.OpenFile(path(dir, h.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("journal: create: %w", err)
+	}
+
+	j := &Journal{
+		header:   h,
+		file:     f,
+		finished: map[string]struct{}{},
+	}
+
+	err = j.write(&record{Type: recordStart, Header: h})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Resume reopens the journal of a run that did not complete, so it may carry
+// on where it stopped. It refuses to if the settings given differ from those
+// the run was started with.
+func Resume(dir, id string, s *Settings) (*Journal, error) {
+	run, err := Read(dir, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if run.Status() == StatusComplete {
+		return nil, fmt.Errorf("%w: %s", ErrComplete, id)
+	}
+
+	err = run.Header.Check(s)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path(dir, id), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("journal: open: %w", err)
+	}
+
+	j := &Journal{
+		header:   run.Header,
+		file:     f,
+		finished: map[string]struct{}{},
+	}
+
+	for _, res := range latest(run.Results) {
+		if res.Status == client.ResultDone || res.Status == client.ResultSkipped {
+			j.finished[strings.ToLower(res.Repo)] = struct{}{}
+		}
+	}
+
+	// a line torn by a crash is terminated, so it does not swallow the
+	// records that follow it
+	_, err = f.Write([]byte("\n"))
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("journal: write: %w", err)
+	}
+
+	err = j.write(&record{Type: recordResume})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Header returns how the run was started.
+func (j *Journal) Header() *Header {
+	return j.header
+}
+
+// Pending returns the repos given, less those the run already finished.
+func (j *Journal) Pending(repos []*github.Repository) []*github.Repository {
+	var pending []*github.Repository
+	for _, r := range repos {
+		key := strings.ToLower(fmt.Sprintf("%v/%v", r.GetOwner().GetLogin(), r.GetName()))
+		if _, ok := j.finished[key]; ok {
+			continue
+		}
+
+		pending = append(pending, r)
+	}
+
+	return pending
+}
+
+// Record appends the result for a repo to the journal, syncing it to disk
+// before returning.
+func (j *Journal) Record(res client.Result) error {
+	return j.write(&record{Type: recordResult, Result: &res})
+}
+
+// Finish records the end of the run, with the error it ended on if any, and
+// closes the journal.
+func (j *Journal) Finish(runErr error) error {
+	rec := &record{Type: recordEnd}
+	if runErr != nil {
+		rec.Error = runErr.Error()
+	}
+
+	err := j.write(rec)
+	if err != nil {
+		j.file.Close()
+		return err
+	}
+
+	err = j.file.Close()
+	if err != nil {
+		return fmt.Errorf("journal: close: %w", err)
+	}
+
+	return nil
+}
+
+func (j *Journal) write(rec *record) error {
+	rec.Time = time.Now()
+
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("journal: marshal: %w", err)
+	}
+
+	_, err = j.file.Write(append(b, '\n'))
+	if err != nil {
+		return fmt.Errorf("journal: write: %w", err)
+	}
+
+	err = j.file.Sync()
+	if err != nil {
+		return fmt.Errorf("journal: sync: %w", err)
+	}
+
+	return nil
+}
+
+// Read returns everything recorded for the run with the id given.
+func Read(dir, id string) (*Run, error) {
+	f, err := os.Open(path(dir, id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+
+		return nil, fmt.Errorf("journal: open: %w", err)
+	}
+	defer f.Close()
+
+	run := &Run{status: StatusInterrupted}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var rec record
+		err := json.Unmarshal(scanner.Bytes(), &rec)
+		if err != nil {
+			// a line torn by a crash mid write is the last one, and is ignored
+			continue
+		}
+
+		switch rec.Type {
+		case recordStart:
+			run.Header = rec.Header
+		case recordResume:
+			run.Resumes++
+			run.status = StatusInterrupted
+			run.Ended = time.Time{}
+			run.Error = ""
+		case recordResult:
+			if rec.Result != nil {
+				run.Results = append(run.Results, *rec.Result)
+			}
+		case recordEnd:
+			run.Ended = rec.Time
+			run.Error = rec.Error
+			run.status = StatusComplete
+			if rec.Error != "" {
+				run.status = StatusFailed
+			}
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("journal: read: %w", err)
+	}
+
+	if run.Header == nil {
+		return nil, fmt.Errorf("journal: %s: missing start record", id)
+	}
+
+	return run, nil
+}
+
+// List returns every run recorded in the directory, most recent first.
+func List(dir string) ([]*Run, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("journal: read dir: %w", err)
+	}
+
+	var runs []*Run
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), fileExt) {
+			continue
+		}
+
+		run, err := Read(dir, strings.TrimSuffix(e.Name(), fileExt))
+		if err != nil {
+			continue
+		}
+
+		runs = append(runs, run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Header.Started.After(runs[j].Header.Started)
+	})
+
+	return runs, nil
+}
+
+// latest returns the most recent result recorded for each repo, in the order
+// the repos were first recorded.
+func latest(results []client.Result) []client.Result {
+	index := map[string]int{}
+	var out []client.Result
+
+	for _, res := range results {
+		key := strings.ToLower(res.Repo)
+		if i, ok := index[key]; ok {
+			out[i] = res
+			continue
+		}
+
+		index[key] = len(out)
+		out = append(out, res)
+	}
+
+	return out
+}
+
+// newID returns an id for a run that sorts by the time it was started.
+func newID() (string, error) {
+	b := make([]byte, 3)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102-150405"), hex.EncodeToString(b)), nil
+}
+
+func path(dir, id string) string {
+	return filepath.Join(dir, filepath.Base(id)+fileExt)
+}
@@ -0,0 +1,85 @@
+// Package plan reads and writes plan files, which record the changes a create
+// or release would make so they may be reviewed before they are applied.
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gomicro/train/client"
+)
+
+// Version is the version of the plan file format written.
+const Version = 1
+
+var ErrVersion = errors.New("unsupported plan version")
+
+// Plan represents the changes planned against a selection of repos, and how
+// the client was configured when they were planned.
+type Plan struct {
+	Version int              `json:"version"`
+	Action  string           `json:"action"`
+	Entity  string           `json:"entity,omitempty"`
+	Profile string           `json:"profile,omitempty"`
+	Created time.Time        `json:"created"`
+	Changes []*client.Change `json:"changes"`
+}
+
+// Write saves the plan to the path given, replacing any file already there
+// only once the plan has been written in full.
+func Write(path string, p *Plan) error {
+	p.Version = Version
+
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("plan: marshal: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".plan-*")
+	if err != nil {
+		return fmt.Errorf("plan: create: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(append(b, '\n'))
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("plan: write: %w", err)
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("plan: close: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("plan: rename: %w", err)
+	}
+
+	return nil
+}
+
+// Read loads the plan saved at the path given.
+func Read(path string) (*Plan, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("plan: read: %w", err)
+	}
+
+	var p Plan
+	err = json.Unmarshal(b, &p)
+	if err != nil {
+		return nil, fmt.Errorf("plan: unmarshal: %w", err)
+	}
+
+	if p.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, p.Version)
+	}
+
+	return &p, nil
+}